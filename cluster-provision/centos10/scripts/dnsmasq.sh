@@ -4,6 +4,7 @@ set -ex
 
 NUM_NODES=${NUM_NODES-1}
 NUM_SECONDARY_NICS=${NUM_SECONDARY_NICS:-0}
+NUM_SRIOV_NICS=${NUM_SRIOV_NICS:-1}
 
 ip link add br0 type bridge
 echo 0 > /proc/sys/net/ipv6/conf/br0/disable_ipv6
@@ -32,6 +33,13 @@ for i in $(seq 1 ${NUM_NODES}); do
   ip link set tap-sriov${n} master br-sriov
   ip link set dev tap-sriov${n} up
 
+  # taps of the SR-IOV NICs beyond the first one
+  for s in $(seq 1 $((${NUM_SRIOV_NICS} - 1))); do
+    ip tuntap add dev tap-sriov${n}-${s} mode tap user $(whoami)
+    ip link set tap-sriov${n}-${s} master br-sriov
+    ip link set dev tap-sriov${n}-${s} up
+  done
+
   for s in $(seq 1 ${NUM_SECONDARY_NICS}); do
     tap_name=stap$(($i - 1))-$(($s - 1))
     ip tuntap add dev $tap_name mode tap user $(whoami)
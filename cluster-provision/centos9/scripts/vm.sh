@@ -26,6 +26,7 @@ while true; do
     -n | --next-disk ) NEXT_DISK="$2"; shift 2 ;;
     -b | --block-device ) BLOCK_DEV="$2"; shift 2 ;;
     -s | --block-device-size ) BLOCK_DEV_SIZE="$2"; shift 2 ;;
+    -e | --extra-block-device-size ) EXTRA_BLOCK_DEV_SIZES+="$2 "; shift 2 ;;
     -n | --nvme-device-size ) NVME_DISK_SIZES+="$2 "; shift 2 ;;
     -t | --scsi-device-size ) SCSI_DISK_SIZES+="$2 "; shift 2 ;;
     -u | --usb-device-size ) USB_SIZES+="$2 "; shift 2 ;;
@@ -108,7 +109,7 @@ function create_ip_rules {
 
 # Route ports from container to VM for first node
 if [ "$n" = "01" ] ; then
-  tcp_ports=( 6443 8443 80 443 30007 30008 31001 30085)
+  tcp_ports=( 6443 8443 80 443 30007 30008 30010 31001 30085)
   create_ip_rules "tcp" "${tcp_ports[@]}"
 
   udp_ports=( 31111 )
@@ -155,6 +156,14 @@ if [ -n "${BLOCK_DEV}" ]; then
   block_dev_drive_arg="-drive format=qcow2,file=${BLOCK_DEV},if=none,id=extdisk,cache=unsafe"
 fi
 
+# extra block devices are attached by gocli, they are created next to the block device
+disk_num=1
+for size in ${EXTRA_BLOCK_DEV_SIZES[@]}; do
+  echo "Creating extra block device of size "$size
+  qemu-img create -f qcow2 "${BLOCK_DEV%.qcow2}-${disk_num}.qcow2" $size
+  let "disk_num+=1"
+done
+
 disk_num=0
 for size in ${NVME_DISK_SIZES[@]}; do
   echo "Creating disk "$size" for NVMe disk emulation"
@@ -0,0 +1,549 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/docker/docker/client"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/cmd/utils"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/docker"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/aaq"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cdi"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/certmanager"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cnao"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/csihostpath"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/dra"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/ingress"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/istio"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/kubevirt"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/kwok"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/localaddon"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/logging"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/metallb"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/multus"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/network_resources_injector"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/nfscsi"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/prometheus"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/rookceph"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/snapshotcontroller"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/sriov"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/velero"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/whereabouts"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+)
+
+// addonFactory creates an addon for a running cluster, nodes are the ssh clients of all nodes, starting with node01.
+// enabled holds the addons the cluster runs or is about to run, so addons can integrate with them the same way they do
+// when both are enabled on cluster creation
+type addonFactory func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, version string, enabled map[string]bool) opts.Addon
+
+var addons = map[string]addonFactory{
+	snapshotcontroller.Name: func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return snapshotcontroller.NewSnapshotControllerOpt(k8sClient)
+	},
+	"ceph": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return rookceph.NewCephOpt(k8sClient, nodes[0], rookceph.DefaultConfig())
+	},
+	"nfs-csi": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return nfscsi.NewNfsCsiOpt(k8sClient, nodes[0], false)
+	},
+	"csi-hostpath": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return csihostpath.NewCsiHostpathOpt(k8sClient, false)
+	},
+	// a running cluster has no minio container, MinIO is deployed in the cluster
+	"velero": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, enabled map[string]bool) opts.Addon {
+		return velero.NewVeleroOpt(k8sClient, nodes[0], true, enabled["kubevirt"])
+	},
+	"multus": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return multus.NewMultusOpt(k8sClient, nodes)
+	},
+	"whereabouts": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, enabled map[string]bool) opts.Addon {
+		return whereabouts.NewWhereaboutsOpt(k8sClient, enabled["multus"], 0)
+	},
+	"cnao": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, enabled map[string]bool) opts.Addon {
+		return cnao.NewCnaoOpt(k8sClient, enabled["multus"], false, false)
+	},
+	"istio": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, enabled map[string]bool) opts.Addon {
+		return istio.NewIstioOpt(nodes[0], k8sClient, enabled["cnao"])
+	},
+	"prometheus": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return prometheus.NewPrometheusOpt(k8sClient, false, false, false, nil)
+	},
+	"logging": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return logging.NewLoggingOpt(k8sClient)
+	},
+	"cdi": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, version string, _ map[string]bool) opts.Addon {
+		return cdi.NewCdiOpt(k8sClient, nodes[0], version)
+	},
+	"aaq": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, version string, _ map[string]bool) opts.Addon {
+		return aaq.NewAaqOpt(k8sClient, version)
+	},
+	"kubevirt": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, version string, _ map[string]bool) opts.Addon {
+		return kubevirt.NewKubevirtOpt(k8sClient, nodes[0], version, "", nil, "", false)
+	},
+	"metallb": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return metallb.NewMetallbOpt(k8sClient, metallb.DefaultAddressPool, nil)
+	},
+	"ingress": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return ingress.NewIngressOpt(k8sClient, ingress.DefaultDomain, ingress.DefaultAddress)
+	},
+	"cert-manager": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return certmanager.NewCertManagerOpt(k8sClient)
+	},
+	"dra": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return dra.NewDraOpt(k8sClient, nodes, dra.DefaultDevices)
+	},
+	"kwok": func(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return kwok.NewKwokOpt(k8sClient, nodes[0], kwok.DefaultNodes, nil)
+	},
+	"sriov": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return sriov.NewSriovOpt(k8sClient)
+	},
+	"network-resources-injector": func(k8sClient k8s.K8sDynamicClient, _ []libssh.Client, _ string, _ map[string]bool) opts.Addon {
+		return network_resources_injector.NewNetworkResourcesInjectorOpt(k8sClient)
+	},
+}
+
+// versionedAddons support deploying another version than the one their manifests ship with
+var versionedAddons = map[string]bool{"cdi": true, "aaq": true, "kubevirt": true}
+
+// NewAddonCommand returns command to manage the add-ons of a running cluster
+func NewAddonCommand() *cobra.Command {
+	addon := &cobra.Command{
+		Use:   "addon",
+		Short: "addon enables, disables and lists the add-ons of a running cluster",
+	}
+
+	enable := &cobra.Command{
+		Use:   "enable <addon|dir>...",
+		Short: "deploy built-in add-ons, or add-ons from directories with an " + localaddon.ManifestFile + ", on a running cluster",
+		RunE:  enableAddon,
+		Args:  cobra.MinimumNArgs(1),
+	}
+	enable.Flags().String("version", "", "version of the add-ons to deploy, only supported by "+strings.Join(sortedKeys(versionedAddons), ", "))
+
+	disable := &cobra.Command{
+		Use:   "disable <addon|dir>",
+		Short: "remove an add-on from a running cluster",
+		RunE:  disableAddon,
+		Args:  cobra.ExactArgs(1),
+	}
+	disable.Flags().Bool("force", false, "remove the add-on even if other installed add-ons depend on it")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "list all built-in add-ons and whether they are installed and ready",
+		RunE:  listAddons,
+		Args:  cobra.NoArgs,
+	}
+
+	sync := &cobra.Command{
+		Use:   "sync <addon>",
+		Short: "deploy the directory of an add-on like prometheus again, content which was removed from it is deleted",
+		RunE:  syncAddon,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	addon.AddCommand(enable, disable, list, sync)
+	return addon
+}
+
+func enableAddon(cmd *cobra.Command, args []string) error {
+	version, err := cmd.Flags().GetString("version")
+	if err != nil {
+		return err
+	}
+
+	k8sClient, nodes, k8sVersion, err := connectToCluster(cmd)
+	if err != nil {
+		return err
+	}
+	return enableAddons(k8sClient, nodes, k8sVersion, args, version)
+}
+
+func disableAddon(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+
+	k8sClient, nodes, _, err := connectToCluster(cmd)
+	if err != nil {
+		return err
+	}
+	return disableAddons(k8sClient, nodes, args[0], force)
+}
+
+func syncAddon(cmd *cobra.Command, args []string) error {
+	k8sClient, nodes, _, err := connectToCluster(cmd)
+	if err != nil {
+		return err
+	}
+	return syncAddons(k8sClient, nodes, args[0])
+}
+
+func listAddons(cmd *cobra.Command, _ []string) error {
+	k8sClient, nodes, _, err := connectToCluster(cmd)
+	if err != nil {
+		return err
+	}
+	return printAddons(cmd.OutOrStdout(), k8sClient, nodes)
+}
+
+// enableAddons deploys the addons on top of the ones which are already installed, addons which are
+// installed already are applied again, e.g. to deploy another version. args are either names of built-in
+// addons or addon directories, nodes are the ssh clients of all nodes, starting with node01. Built-in addons which
+// the built-in addons depend on are enabled with them. Addons which do not support the Kubernetes version of the
+// cluster are refused
+func enableAddons(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, k8sVersion *semver.Version, args []string, version string) error {
+	local := map[string]*localaddon.Addon{}
+	names := []string{}
+	for _, arg := range args {
+		if version != "" && !versionedAddons[arg] {
+			return fmt.Errorf("--version is only supported by %s", strings.Join(sortedKeys(versionedAddons), ", "))
+		}
+		if isAddonDir(arg) {
+			addon, err := loadLocalAddon(arg)
+			if err != nil {
+				return err
+			}
+			local[arg] = addon
+			names = append(names, addon.Name)
+			continue
+		}
+		if _, exists := addons[arg]; !exists {
+			return unknownAddonError(arg)
+		}
+		names = append(names, arg)
+	}
+
+	statuses, err := addonStatuses(k8sClient, nodes)
+	if err != nil {
+		return err
+	}
+	installed := installedAddons(statuses)
+	enabled := installedAddons(statuses)
+	for _, name := range names {
+		delete(installed, name)
+		enabled[name] = true
+	}
+
+	toEnable := []opts.ScheduledOpt{}
+	for _, arg := range args {
+		if addon, isLocal := local[arg]; isLocal {
+			toEnable = append(toEnable, localaddon.NewLocalAddonOpt(k8sClient, nodes, addon))
+			continue
+		}
+		toEnable = append(toEnable, addons[arg](k8sClient, nodes, version, enabled))
+	}
+
+	// built-in addons bring the built-in addons they depend on, unless those are enabled already
+	for i := 0; i < len(toEnable); i++ {
+		d := toEnable[i].Descriptor()
+		if _, builtIn := addons[d.Name]; !builtIn {
+			continue
+		}
+		for _, dep := range d.Dependencies {
+			if _, builtIn := addons[dep]; !builtIn || enabled[dep] {
+				continue
+			}
+			logrus.Infof("Enabling %s, %s depends on it", dep, d.Name)
+			enabled[dep] = true
+			toEnable = append(toEnable, addons[dep](k8sClient, nodes, "", enabled))
+		}
+	}
+
+	results, err := opts.ScheduleOn(k8sVersion, sortedKeys(installed), toEnable...)
+	if err != nil {
+		return err
+	}
+	notEnabled := []string{}
+	for _, result := range results {
+		if result.Skipped {
+			notEnabled = append(notEnabled, fmt.Sprintf("%s was not enabled, %s", result.Name, result.Reason))
+		}
+	}
+	if len(notEnabled) > 0 {
+		return errors.New(strings.Join(notEnabled, "; "))
+	}
+	return nil
+}
+
+// disableAddons uninstalls the built-in addon or the addon of the directory,
+// unless other installed addons depend on it and force is not set
+func disableAddons(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, arg string, force bool) error {
+	name := arg
+	var addon opts.Addon
+	if isAddonDir(arg) {
+		local, err := loadLocalAddon(arg)
+		if err != nil {
+			return err
+		}
+		name = local.Name
+		addon = localaddon.NewLocalAddonOpt(k8sClient, nodes, local)
+	} else if _, exists := addons[name]; !exists {
+		return unknownAddonError(name)
+	}
+
+	statuses, err := addonStatuses(k8sClient, nodes)
+	if err != nil {
+		return err
+	}
+	if addon != nil {
+		if statuses[name], err = addon.Status(); err != nil {
+			return fmt.Errorf("error checking the status of %s: %w", name, err)
+		}
+	}
+	if !statuses[name].Installed {
+		logrus.Infof("%s is not installed", name)
+		return nil
+	}
+	installed := installedAddons(statuses)
+	if addon == nil {
+		addon = addons[name](k8sClient, nodes, "", installed)
+	}
+
+	dependents := []string{}
+	for _, other := range sortedKeys(installed) {
+		factory, builtIn := addons[other]
+		if !builtIn {
+			continue
+		}
+		descriptor := factory(k8sClient, nodes, "", installed).Descriptor()
+		for _, dep := range descriptor.Dependencies {
+			if dep == name {
+				dependents = append(dependents, other)
+			}
+		}
+	}
+	// add-ons deployed from directories record their dependencies on the cluster
+	local, err := localaddon.Installed(k8sClient)
+	if err != nil {
+		return fmt.Errorf("error listing the installed add-on directories: %w", err)
+	}
+	for _, manifest := range local {
+		for _, dep := range manifest.Dependencies {
+			if dep == name && manifest.Name != name {
+				dependents = append(dependents, manifest.Name)
+			}
+		}
+	}
+	if len(dependents) > 0 {
+		if !force {
+			return fmt.Errorf("%s is required by %s, disable them first or use --force", name, strings.Join(dependents, ", "))
+		}
+		logrus.Warnf("Disabling %s although %s depend on it", name, strings.Join(dependents, ", "))
+	}
+
+	if err := addon.Uninstall(); err != nil {
+		return fmt.Errorf("error disabling %s: %w", name, err)
+	}
+	logrus.Infof("%s disabled", name)
+	return nil
+}
+
+// syncAddons deploys the directory of an installed addon again, only addons which deploy content of a directory on
+// the host can be synced
+func syncAddons(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client, name string) error {
+	factory, exists := addons[name]
+	if !exists {
+		return unknownAddonError(name)
+	}
+	addon := factory(k8sClient, nodes, "", nil)
+	syncer, ok := addon.(opts.Syncer)
+	if !ok {
+		return fmt.Errorf("%s has no directory which could be synced", name)
+	}
+
+	status, err := addon.Status()
+	if err != nil {
+		return fmt.Errorf("error checking the status of %s: %w", name, err)
+	}
+	if !status.Installed {
+		return fmt.Errorf("%s is not installed", name)
+	}
+	return syncer.Sync()
+}
+
+func printAddons(out io.Writer, k8sClient k8s.K8sDynamicClient, nodes []libssh.Client) error {
+	statuses, err := addonStatuses(k8sClient, nodes)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tMESSAGE")
+	for _, name := range sortedKeys(addons) {
+		status := statuses[name]
+		state := "disabled"
+		switch {
+		case status.Ready:
+			state = "ready"
+		case status.Installed:
+			state = "not ready"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, state, status.Message)
+	}
+	return w.Flush()
+}
+
+func addonStatuses(k8sClient k8s.K8sDynamicClient, nodes []libssh.Client) (map[string]opts.Status, error) {
+	statuses := map[string]opts.Status{}
+	for name, factory := range addons {
+		status, err := factory(k8sClient, nodes, "", nil).Status()
+		if err != nil {
+			return nil, fmt.Errorf("error checking the status of %s: %w", name, err)
+		}
+		statuses[name] = status
+	}
+	return statuses, nil
+}
+
+func installedAddons(statuses map[string]opts.Status) map[string]bool {
+	installed := map[string]bool{}
+	for name, status := range statuses {
+		if status.Installed {
+			installed[name] = true
+		}
+	}
+	return installed
+}
+
+// isAddonDir reports whether arg is an addon directory rather than the name of a built-in addon
+func isAddonDir(arg string) bool {
+	_, err := os.Stat(filepath.Join(arg, localaddon.ManifestFile))
+	return err == nil
+}
+
+// loadLocalAddon loads the addon directory, its name must not shadow a built-in addon
+func loadLocalAddon(dir string) (*localaddon.Addon, error) {
+	addon, err := localaddon.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := addons[addon.Name]; exists {
+		return nil, fmt.Errorf("add-on %s in %s has the name of a built-in add-on", addon.Name, dir)
+	}
+	return addon, nil
+}
+
+func unknownAddonError(name string) error {
+	return fmt.Errorf("unknown addon %s, available addons are %s", name, strings.Join(sortedKeys(addons), ", "))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// connectToCluster creates the clients for the running cluster of the prefix, the kubeconfig is copied from the first node.
+// The ssh clients of all nodes but the fake ones of kwok are returned in the order of the nodes, starting with node01,
+// together with the Kubernetes version of the cluster
+func connectToCluster(cmd *cobra.Command) (k8s.K8sDynamicClient, []libssh.Client, *semver.Version, error) {
+	prefix, err := cmd.Flags().GetString("prefix")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	containers, err := docker.GetPrefixedContainers(cli, prefix+"-dnsmasq")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(containers) != 1 {
+		return nil, nil, nil, fmt.Errorf("failed to find the cluster with prefix %s, is it running?", prefix)
+	}
+	dm, err := cli.ContainerInspect(context.Background(), containers[0].ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sshPort, err := utils.GetPublicPort(utils.PortSSH, dm.NetworkSettings.Ports)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	apiServerPort, err := utils.GetPublicPort(utils.PortAPI, dm.NetworkSettings.Ports)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sshClient, err := libssh.NewSSHClient(sshPort, 1, true)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	kubeConfFile, err := os.CreateTemp("", "kubeconfig")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer os.Remove(kubeConfFile.Name())
+	defer kubeConfFile.Close()
+
+	if err := sshClient.CopyRemoteFile("/etc/kubernetes/admin.conf", kubeConfFile); err != nil {
+		return nil, nil, nil, err
+	}
+
+	config, err := k8s.NewConfig(kubeConfFile.Name(), apiServerPort)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	k8sClient, err := k8s.NewDynamicClient(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	k8sVersion, err := k8s.ServerVersion(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nodeCount, err := countNodes(k8sClient)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nodes := []libssh.Client{sshClient}
+	for x := 2; x <= nodeCount; x++ {
+		nodeClient, err := libssh.NewSSHClient(sshPort, x, true)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		nodes = append(nodes, nodeClient)
+	}
+	return k8sClient, nodes, k8sVersion, nil
+}
+
+// countNodes counts the nodes of the cluster which run in containers, the fake nodes of kwok have no container
+// which could be reached with ssh
+func countNodes(k8sClient k8s.K8sDynamicClient) (int, error) {
+	nodeList, err := k8sClient.List(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, "")
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, node := range nodeList.Items {
+		if !kwok.IsFakeNode(&node) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/multus"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/prometheus"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+var _ = Describe("Addons", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		sshClient *kubevirtcimocks.MockSSHClient
+		nodes     []libssh.Client
+		k8sClient k8s.K8sDynamicClient
+	)
+
+	k8sVersion := semver.MustParse("1.31.0")
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		nodes = []libssh.Client{sshClient}
+		k8sClient = k8s.NewTestClient()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should register every addon under the name it is scheduled as", func() {
+		for name, factory := range addons {
+			Expect(factory(k8sClient, nodes, "", nil).Descriptor().Name).To(Equal(name))
+		}
+	})
+
+	It("should list enabled addons and their readiness", func() {
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{"multus", "cdi"}, "")).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(printAddons(out, k8sClient, nodes)).To(Succeed())
+		Expect(out.String()).To(MatchRegexp(`multus\s+ready`))
+		Expect(out.String()).To(MatchRegexp(`cdi\s+not ready\s+condition Available not reported yet`))
+		Expect(out.String()).To(MatchRegexp(`cnao\s+disabled`))
+	})
+
+	It("should let addons integrate with addons enabled before", func() {
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{"multus"}, "")).To(Succeed())
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{"cnao"}, "")).To(Succeed())
+
+		config, err := k8sClient.Get(schema.GroupVersionKind{Group: "networkaddonsoperator.network.kubevirt.io", Version: "v1", Kind: "NetworkAddonsConfig"}, "cluster", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Object["spec"]).NotTo(HaveKey("multus"))
+	})
+
+	It("should refuse to disable addons other addons depend on", func() {
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{"multus", "cnao"}, "")).To(Succeed())
+
+		Expect(disableAddons(k8sClient, nodes, "multus", false)).To(MatchError("multus is required by cnao, disable them first or use --force"))
+		Expect(disableAddons(k8sClient, nodes, "cnao", false)).To(Succeed())
+		multus.AddUninstallExpectCalls(sshClient)
+		Expect(disableAddons(k8sClient, nodes, "multus", false)).To(Succeed())
+
+		statuses, err := addonStatuses(k8sClient, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installedAddons(statuses)).To(BeEmpty())
+	})
+
+	It("should enable the built-in addons an addon depends on", func() {
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{"csi-hostpath"}, "")).To(Succeed())
+
+		statuses, err := addonStatuses(k8sClient, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installedAddons(statuses)).To(Equal(map[string]bool{"csi-hostpath": true, "snapshot-controller": true}))
+		Expect(disableAddons(k8sClient, nodes, "snapshot-controller", false)).To(MatchError("snapshot-controller is required by csi-hostpath, disable them first or use --force"))
+	})
+
+	It("should enable add-on directories on top of the built-in add-ons", func() {
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "addon.yaml"), []byte("name: widgets\ndependencies: [multus]\nmanifests: [ns.yaml]\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "ns.yaml"), []byte("apiVersion: v1\nkind: Namespace\nmetadata:\n  name: widgets\n"), 0644)).To(Succeed())
+
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{dir}, "")).To(MatchError("widgets depends on multus, which is not enabled"))
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{"multus"}, "")).To(Succeed())
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{dir}, "")).To(Succeed())
+
+		namespaceGVK := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+		_, err := k8sClient.Get(namespaceGVK, "widgets", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(disableAddons(k8sClient, nodes, "multus", false)).To(MatchError("multus is required by widgets, disable them first or use --force"))
+		Expect(disableAddons(k8sClient, nodes, dir, false)).To(Succeed())
+		_, err = k8sClient.Get(namespaceGVK, "widgets", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		multus.AddUninstallExpectCalls(sshClient)
+		Expect(disableAddons(k8sClient, nodes, "multus", false)).To(Succeed())
+	})
+
+	It("should refuse add-on directories shadowing built-in add-ons", func() {
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "addon.yaml"), []byte("name: cdi\nmanifests: [cdi.yaml]\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "cdi.yaml"), []byte{}, 0644)).To(Succeed())
+
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, []string{dir}, "")).To(MatchError(ContainSubstring("has the name of a built-in add-on")))
+	})
+
+	It("should refuse addons which do not support the Kubernetes version of the cluster", func() {
+		Expect(enableAddons(k8sClient, nodes, semver.MustParse("1.29.0"), []string{"aaq", "multus"}, "")).To(MatchError("aaq was not enabled, it requires Kubernetes >=1.30 but the cluster runs 1.29.0"))
+
+		statuses, err := addonStatuses(k8sClient, nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installedAddons(statuses)).To(Equal(map[string]bool{"multus": true}))
+	})
+
+	It("should sync the directory addons were deployed with", func() {
+		Expect(syncAddons(k8sClient, nodes, "multus")).To(MatchError("multus has no directory which could be synced"))
+		Expect(syncAddons(k8sClient, nodes, "prometheus")).To(MatchError("prometheus is not installed"))
+
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "dashboard.json"), []byte("{}"), 0644)).To(Succeed())
+		extra, err := prometheus.LoadExtra(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(prometheus.NewPrometheusOpt(k8sClient, true, false, false, extra).Exec()).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(dir, "rule.yaml"), []byte("apiVersion: monitoring.coreos.com/v1\nkind: PrometheusRule\nmetadata:\n  name: kubevirt-dev\n"), 0644)).To(Succeed())
+		Expect(syncAddons(k8sClient, nodes, "prometheus")).To(Succeed())
+		_, err = k8sClient.Get(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}, "kubevirt-dev", "monitoring")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should only count the nodes which run in containers", func() {
+		for _, node := range []string{"node01", "node02", "kwok-node-0"} {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("Node")
+			obj.SetName(node)
+			if strings.HasPrefix(node, "kwok") {
+				obj.SetAnnotations(map[string]string{"kwok.x-k8s.io/node": "fake"})
+			}
+			Expect(k8sClient.Apply(obj)).To(Succeed())
+		}
+
+		Expect(countNodes(k8sClient)).To(Equal(2))
+	})
+
+	DescribeTable("should refuse invalid requests", func(names []string, version, expected string) {
+		Expect(enableAddons(k8sClient, nodes, k8sVersion, names, version)).To(MatchError(ContainSubstring(expected)))
+	},
+		Entry("unknown addon", []string{"hco"}, "", "unknown addon hco"),
+		Entry("version of an unversioned addon", []string{"cdi", "multus"}, "v1.60.0", "--version is only supported by aaq, cdi, kubevirt"),
+	)
+})
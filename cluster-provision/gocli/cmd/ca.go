@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/certmanager"
+)
+
+// NewCACommand returns command to export the CA of the cluster, so host tools can trust certificates issued in it
+func NewCACommand() *cobra.Command {
+	ca := &cobra.Command{
+		Use:   "ca",
+		Short: "ca prints the PEM encoded CA of the " + certmanager.CAIssuer + " ClusterIssuer, the cluster has to be created with --deploy-cert-manager",
+		RunE:  exportCA,
+		Args:  cobra.NoArgs,
+	}
+	ca.Flags().StringP("output", "o", "", "file to write the CA to instead of stdout")
+
+	return ca
+}
+
+func exportCA(cmd *cobra.Command, _ []string) error {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+
+	k8sClient, _, _, err := connectToCluster(cmd)
+	if err != nil {
+		return err
+	}
+	ca, err := certmanager.CA(k8sClient)
+	if err != nil {
+		return err
+	}
+
+	if output != "" {
+		return os.WriteFile(output, ca, 0644)
+	}
+	_, err = cmd.OutOrStdout().Write(ca)
+	return err
+}
@@ -1,20 +1,28 @@
 package nodesconfig
 
+import (
+	"github.com/Masterminds/semver/v3"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cni"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/rookceph"
+)
+
 // NodeLinuxConfig type holds the config params that a node can have for its linux system
 type NodeLinuxConfig struct {
 	NodeIdx               int
-	K8sVersion            string
+	Prefix                string
+	K8sVersion            *semver.Version
 	FipsEnabled           bool
 	DockerProxy           string
 	EtcdInMemory          bool
 	EtcdSize              string
 	SingleStack           bool
-	Flannel               bool
+	CNI                   string
 	NoEtcdFsync           bool
 	EnableAudit           bool
 	GpuAddress            string
 	Realtime              bool
 	PSA                   bool
+	PSAConfig             []byte
 	KsmEnabled            bool
 	SwapEnabled           bool
 	KsmPageCount          int
@@ -26,29 +34,63 @@ type NodeLinuxConfig struct {
 	VsockChildNsMode      string
 	TopologyManagerPolicy string
 	ReservedSystemCPUs    string
+	SriovVFs              int
 }
 
 // NodeK8sConfig type holds the config k8s options for kubevirt cluster
 type NodeK8sConfig struct {
 	Ceph                     bool
+	CephConfig               rookceph.Config
 	Prometheus               bool
 	Alertmanager             bool
 	Grafana                  bool
+	Logging                  bool
+	PrometheusExtra          string
 	Istio                    bool
 	NfsCsi                   bool
+	NfsSecondExport          bool
 	CNAO                     bool
 	CNAOSkipCR               bool
 	Multus                   bool
+	Whereabouts              bool
+	WhereaboutsNetworks      uint
 	CDI                      bool
 	CDIVersion               string
 	AAQ                      bool
 	AAQVersion               string
 	DNC                      bool
 	NetworkResourcesInjector bool
+	Addons                   []string
+	KubeVirt                 bool
+	KubeVirtVersion          string
+	KubeVirtManifests        string
+	KubeVirtFeatureGates     []string
+	KubeVirtConfig           string
+	KubeVirtAutoEmulation    bool
+	MetalLB                  bool
+	MetalLBAddressPool       string
+	MetalLBInterfaces        []string
+	Ingress                  bool
+	IngressDomain            string
+	IngressAddress           string
+	CertManager              bool
+	Kwok                     bool
+	KwokNodes                int
+	KwokNodeTemplate         string
+	DRA                      bool
+	DRADevices               int
+	Sriov                    bool
+	CsiHostpath              bool
+	CsiHostpathDefault       bool
+	Velero                   bool
+	VeleroInClusterMinIO     bool
+	VeleroKubeVirtPlugin     bool
 }
 
 func NewNodeK8sConfig(confs []K8sConfigFunc) *NodeK8sConfig {
-	n := &NodeK8sConfig{}
+	n := &NodeK8sConfig{
+		CephConfig: rookceph.DefaultConfig(),
+	}
 
 	for _, conf := range confs {
 		conf(n)
@@ -57,10 +99,12 @@ func NewNodeK8sConfig(confs []K8sConfigFunc) *NodeK8sConfig {
 	return n
 }
 
-func NewNodeLinuxConfig(nodeIdx int, k8sVersion string, confs []LinuxConfigFunc) *NodeLinuxConfig {
+func NewNodeLinuxConfig(nodeIdx int, prefix string, k8sVersion *semver.Version, confs []LinuxConfigFunc) *NodeLinuxConfig {
 	n := &NodeLinuxConfig{
 		NodeIdx:    nodeIdx,
+		Prefix:     prefix,
 		K8sVersion: k8sVersion,
+		CNI:        cni.DefaultName,
 	}
 
 	for _, conf := range confs {
@@ -1,5 +1,7 @@
 package nodesconfig
 
+import "kubevirt.io/kubevirtci/cluster-provision/gocli/opts/rookceph"
+
 type LinuxConfigFunc func(n *NodeLinuxConfig)
 
 type K8sConfigFunc func(n *NodeK8sConfig)
@@ -40,9 +42,9 @@ func WithSingleStack(singleStack bool) LinuxConfigFunc {
 	}
 }
 
-func WithFlannel(flannel bool) LinuxConfigFunc {
+func WithCNI(cni string) LinuxConfigFunc {
 	return func(n *NodeLinuxConfig) {
-		n.Flannel = flannel
+		n.CNI = cni
 	}
 }
 
@@ -76,6 +78,12 @@ func WithPSA(psa bool) LinuxConfigFunc {
 	}
 }
 
+func WithPSAConfig(config []byte) LinuxConfigFunc {
+	return func(n *NodeLinuxConfig) {
+		n.PSAConfig = config
+	}
+}
+
 func WithKsm(ksm bool) LinuxConfigFunc {
 	return func(n *NodeLinuxConfig) {
 		n.KsmEnabled = ksm
@@ -154,12 +162,24 @@ func WithReservedSystemCPUs(cpuset string) LinuxConfigFunc {
 	}
 }
 
+func WithSriovVFs(vfs int) LinuxConfigFunc {
+	return func(n *NodeLinuxConfig) {
+		n.SriovVFs = vfs
+	}
+}
+
 func WithCeph(ceph bool) K8sConfigFunc {
 	return func(n *NodeK8sConfig) {
 		n.Ceph = ceph
 	}
 }
 
+func WithCephConfig(config rookceph.Config) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.CephConfig = config
+	}
+}
+
 func WithPrometheus(prometheus bool) K8sConfigFunc {
 	return func(n *NodeK8sConfig) {
 		n.Prometheus = prometheus
@@ -178,6 +198,18 @@ func WithGrafana(grafana bool) K8sConfigFunc {
 	}
 }
 
+func WithPrometheusExtra(dir string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.PrometheusExtra = dir
+	}
+}
+
+func WithLogging(logging bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Logging = logging
+	}
+}
+
 func WithIstio(istio bool) K8sConfigFunc {
 	return func(n *NodeK8sConfig) {
 		n.Istio = istio
@@ -190,6 +222,12 @@ func WithNfsCsi(nfsCsi bool) K8sConfigFunc {
 	}
 }
 
+func WithNfsSecondExport(secondExport bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.NfsSecondExport = secondExport
+	}
+}
+
 func WithCnao(cnao bool) K8sConfigFunc {
 	return func(n *NodeK8sConfig) {
 		n.CNAO = cnao
@@ -247,3 +285,165 @@ func WithNetworkResourcesInjector(networkResourcesInjector bool) K8sConfigFunc {
 		n.NetworkResourcesInjector = networkResourcesInjector
 	}
 }
+
+func WithAddons(addons []string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Addons = addons
+	}
+}
+
+func WithKubeVirt(kubevirt bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KubeVirt = kubevirt
+	}
+}
+
+func WithKubeVirtVersion(kubevirtVersion string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KubeVirtVersion = kubevirtVersion
+	}
+}
+
+func WithKubeVirtManifests(kubevirtManifests string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KubeVirtManifests = kubevirtManifests
+	}
+}
+
+func WithKubeVirtFeatureGates(featureGates []string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KubeVirtFeatureGates = featureGates
+	}
+}
+
+func WithKubeVirtConfig(kubevirtConfig string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KubeVirtConfig = kubevirtConfig
+	}
+}
+
+func WithKubeVirtAutoEmulation(autoEmulation bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KubeVirtAutoEmulation = autoEmulation
+	}
+}
+
+func WithMetalLB(metallb bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.MetalLB = metallb
+	}
+}
+
+func WithMetalLBAddressPool(addressPool string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.MetalLBAddressPool = addressPool
+	}
+}
+
+func WithMetalLBInterfaces(interfaces []string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.MetalLBInterfaces = interfaces
+	}
+}
+
+func WithIngress(ingress bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Ingress = ingress
+	}
+}
+
+func WithIngressDomain(domain string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.IngressDomain = domain
+	}
+}
+
+func WithIngressAddress(address string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.IngressAddress = address
+	}
+}
+
+func WithCertManager(certManager bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.CertManager = certManager
+	}
+}
+
+func WithKwok(kwok bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Kwok = kwok
+	}
+}
+
+func WithKwokNodes(nodes int) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KwokNodes = nodes
+	}
+}
+
+func WithKwokNodeTemplate(template string) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.KwokNodeTemplate = template
+	}
+}
+
+func WithWhereabouts(whereabouts bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Whereabouts = whereabouts
+	}
+}
+
+func WithWhereaboutsNetworks(networks uint) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.WhereaboutsNetworks = networks
+	}
+}
+
+func WithDRA(dra bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.DRA = dra
+	}
+}
+
+func WithDRADevices(devices int) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.DRADevices = devices
+	}
+}
+
+func WithSriov(sriov bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Sriov = sriov
+	}
+}
+
+func WithCsiHostpath(csiHostpath bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.CsiHostpath = csiHostpath
+	}
+}
+
+func WithCsiHostpathDefault(makeDefault bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.CsiHostpathDefault = makeDefault
+	}
+}
+
+func WithVelero(velero bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.Velero = velero
+	}
+}
+
+func WithVeleroInClusterMinIO(inClusterMinIO bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.VeleroInClusterMinIO = inClusterMinIO
+	}
+}
+
+func WithVeleroKubeVirtPlugin(kubevirtPlugin bool) K8sConfigFunc {
+	return func(n *NodeK8sConfig) {
+		n.VeleroKubeVirtPlugin = kubevirtPlugin
+	}
+}
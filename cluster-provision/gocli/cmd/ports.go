@@ -1,16 +1,31 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/docker/docker/client"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/cmd/utils"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/docker"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 )
 
+// loadBalancerRule forwards a reserved port of the dnsmasq container to a LoadBalancer IP, rootless podman
+// needs the rule in the OUTPUT chain like the other forwarded ports
+const loadBalancerRule = `chain="PREROUTING -i eth0"
+if grep -q '^rootless=1' /run/.containerenv 2>/dev/null; then chain=OUTPUT; fi
+iptables -t nat -A ${chain} -p tcp -m tcp --dport %d -j DNAT --to-destination %s`
+
+var dnatRuleRegex = regexp.MustCompile(`--dport ([0-9]+) .*--to-destination ([^ \r\n]+)`)
+
 // NewPortCommand returns new command to expose public ports for the cluster
 func NewPortCommand() *cobra.Command {
 
@@ -22,7 +37,7 @@ func NewPortCommand() *cobra.Command {
 If no port name is specified, all exposed ports are printed.
 If an extra port name is specified, only the exposed port is printed.
 
-Known port names are 'ssh', 'registry', 'ocp', 'k8s', 'prometheus' and 'grafana'.
+Known port names are 'ssh', 'registry', 'ocp', 'k8s', 'prometheus', 'grafana', 'loki' and 'minio'.
 `,
 		RunE: ports,
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -32,7 +47,7 @@ Known port names are 'ssh', 'registry', 'ocp', 'k8s', 'prometheus' and 'grafana'
 
 			if len(args) == 1 {
 				switch args[0] {
-				case utils.PortNameSSH, utils.PortNameSSHWorker, utils.PortNameAPI, utils.PortNameOCP, utils.PortNameOCPConsole, utils.PortNameRegistry, utils.PortNameVNC, utils.PortNameHTTP, utils.PortNameHTTPS, utils.PortNamePrometheus, utils.PortNameGrafana, utils.PortNameUploadProxy, utils.PortNameDNS, utils.PortNameUploadProxyLowerBand:
+				case utils.PortNameSSH, utils.PortNameSSHWorker, utils.PortNameAPI, utils.PortNameOCP, utils.PortNameOCPConsole, utils.PortNameRegistry, utils.PortNameVNC, utils.PortNameHTTP, utils.PortNameHTTPS, utils.PortNamePrometheus, utils.PortNameGrafana, utils.PortNameUploadProxy, utils.PortNameDNS, utils.PortNameUploadProxyLowerBand, utils.PortNameLoki, utils.PortNameMinIO:
 					return nil
 				default:
 					return fmt.Errorf("unknown port name %s", args[0])
@@ -44,6 +59,21 @@ Known port names are 'ssh', 'registry', 'ocp', 'k8s', 'prometheus' and 'grafana'
 
 	port.Flags().String("container-name", "dnsmasq", "the container name to SSH copy from")
 
+	loadBalancer := &cobra.Command{
+		Use:     "loadbalancer <namespace>/<service>",
+		Aliases: []string{"lb"},
+		Short:   "publishes the LoadBalancer IP of a service on a host port and prints the port",
+		Long: `publishes the LoadBalancer IP of a service on a host port and prints the port
+
+The cluster has to be created with --enable-metallb, which reserves the ports.
+Publishing a service again prints the port it is already published on.
+`,
+		RunE: publishLoadBalancer,
+		Args: cobra.ExactArgs(1),
+	}
+	loadBalancer.Flags().Int64("port", 0, "port of the service to publish, defaults to its first TCP port")
+	port.AddCommand(loadBalancer)
+
 	return port
 }
 
@@ -113,6 +143,10 @@ func ports(cmd *cobra.Command, args []string) error {
 			err = utils.PrintPublicPort(utils.PortUploadProxyLowerBand, container.NetworkSettings.Ports)
 		case utils.PortNameDNS:
 			err = utils.PrintPublicPort(utils.PortDNS, container.NetworkSettings.Ports)
+		case utils.PortNameLoki:
+			err = utils.PrintPublicPort(utils.PortLoki, container.NetworkSettings.Ports)
+		case utils.PortNameMinIO:
+			err = utils.PrintPublicPort(utils.PortMinIOConsole, container.NetworkSettings.Ports)
 		}
 
 		if err != nil {
@@ -129,3 +163,146 @@ func ports(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func publishLoadBalancer(cmd *cobra.Command, args []string) error {
+	namespace, name, found := strings.Cut(args[0], "/")
+	if !found {
+		return fmt.Errorf("service has to be given as <namespace>/<service>")
+	}
+
+	servicePort, err := cmd.Flags().GetInt64("port")
+	if err != nil {
+		return err
+	}
+
+	prefix, err := cmd.Flags().GetString("prefix")
+	if err != nil {
+		return err
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		return err
+	}
+
+	containers, err := docker.GetPrefixedContainers(cli, prefix+"-dnsmasq")
+	if err != nil {
+		return err
+	}
+	if len(containers) != 1 {
+		return fmt.Errorf("failed to find the cluster with prefix %s, is it running?", prefix)
+	}
+	dm, err := cli.ContainerInspect(context.Background(), containers[0].ID)
+	if err != nil {
+		return err
+	}
+	if _, err := utils.GetPublicPort(utils.PortLoadBalancer, dm.NetworkSettings.Ports); err != nil {
+		return fmt.Errorf("no ports are reserved for LoadBalancer IPs, the cluster has to be created with --enable-metallb")
+	}
+
+	k8sClient, _, _, err := connectToCluster(cmd)
+	if err != nil {
+		return err
+	}
+	target, err := loadBalancerTarget(k8sClient, namespace, name, servicePort)
+	if err != nil {
+		return err
+	}
+
+	rules := &bytes.Buffer{}
+	success, err := docker.Exec(cli, dm.ID, []string{"iptables", "-t", "nat", "-S"}, rules)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("listing the forwarded ports failed: %s", rules.String())
+	}
+	containerPort, published, err := loadBalancerPort(rules.String(), target)
+	if err != nil {
+		return err
+	}
+	if !published {
+		out := &bytes.Buffer{}
+		success, err := docker.Exec(cli, dm.ID, []string{"/bin/bash", "-c", fmt.Sprintf(loadBalancerRule, containerPort, target)}, out)
+		if err != nil {
+			return err
+		}
+		if !success {
+			return fmt.Errorf("forwarding port %d to %s failed: %s", containerPort, target, out.String())
+		}
+	}
+
+	hostPort, err := utils.GetPublicPort(containerPort, dm.NetworkSettings.Ports)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), hostPort)
+	return nil
+}
+
+// loadBalancerTarget returns the LoadBalancer IP and port of the service as ip:port, servicePort selects
+// the port of the service, its first TCP port is used if servicePort is 0
+func loadBalancerTarget(k8sClient k8s.K8sDynamicClient, namespace, name string, servicePort int64) (string, error) {
+	service, err := k8sClient.Get(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, name, namespace)
+	if err != nil {
+		return "", err
+	}
+	if serviceType, _, _ := unstructured.NestedString(service.Object, "spec", "type"); serviceType != "LoadBalancer" {
+		return "", fmt.Errorf("service %s/%s is not of type LoadBalancer", namespace, name)
+	}
+
+	ingress, _, err := unstructured.NestedSlice(service.Object, "status", "loadBalancer", "ingress")
+	if err != nil {
+		return "", err
+	}
+	ip := ""
+	if len(ingress) > 0 {
+		ip, _, _ = unstructured.NestedString(ingress[0].(map[string]interface{}), "ip")
+	}
+	if ip == "" {
+		return "", fmt.Errorf("service %s/%s has no LoadBalancer IP yet", namespace, name)
+	}
+
+	ports, _, err := unstructured.NestedSlice(service.Object, "spec", "ports")
+	if err != nil {
+		return "", err
+	}
+	for _, p := range ports {
+		port := p.(map[string]interface{})
+		protocol, found, _ := unstructured.NestedString(port, "protocol")
+		if found && protocol != "TCP" {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(port, "port")
+		if servicePort == 0 || number == servicePort {
+			return fmt.Sprintf("%s:%d", ip, number), nil
+		}
+	}
+	if servicePort != 0 {
+		return "", fmt.Errorf("service %s/%s has no TCP port %d", namespace, name, servicePort)
+	}
+	return "", fmt.Errorf("service %s/%s has no TCP port", namespace, name)
+}
+
+// loadBalancerPort returns the reserved port which forwards to target according to the nat rules of the dnsmasq
+// container, or the first free reserved port if target is not published yet
+func loadBalancerPort(rules, target string) (uint16, bool, error) {
+	used := map[int]bool{}
+	for _, match := range dnatRuleRegex.FindAllStringSubmatch(rules, -1) {
+		port, err := strconv.Atoi(match[1])
+		if err != nil || port < utils.PortLoadBalancer || port >= utils.PortLoadBalancer+utils.LoadBalancerPortCount {
+			continue
+		}
+		if match[2] == target {
+			return uint16(port), true, nil
+		}
+		used[port] = true
+	}
+
+	for port := utils.PortLoadBalancer; port < utils.PortLoadBalancer+utils.LoadBalancerPortCount; port++ {
+		if !used[port] {
+			return uint16(port), false, nil
+		}
+	}
+	return 0, false, fmt.Errorf("all %d ports reserved for LoadBalancer IPs are in use", utils.LoadBalancerPortCount)
+}
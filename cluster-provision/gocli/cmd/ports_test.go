@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+const natRules = "-P PREROUTING ACCEPT\r\n" +
+	"-A PREROUTING -i eth0 -p tcp -m tcp --dport 6443 -j DNAT --to-destination 192.168.66.101:6443\r\n" +
+	"-A PREROUTING -i eth0 -p tcp -m tcp --dport 32600 -j DNAT --to-destination 192.168.66.201:80\r\n" +
+	"-A OUTPUT -p tcp -m tcp --dport 32601 -j DNAT --to-destination 192.168.66.202:443\r\n"
+
+var _ = Describe("Ports", func() {
+	DescribeTable("should pick the reserved port for a LoadBalancer IP", func(target string, expectedPort int, expectedPublished bool) {
+		port, published, err := loadBalancerPort(natRules, target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(BeEquivalentTo(expectedPort))
+		Expect(published).To(Equal(expectedPublished))
+	},
+		Entry("already published", "192.168.66.202:443", 32601, true),
+		Entry("not published yet", "192.168.66.203:80", 32602, false),
+		Entry("forwarded by another rule", "192.168.66.101:6443", 32602, false),
+	)
+
+	It("should fail once all reserved ports are in use", func() {
+		rules := ""
+		for port := 32600; port < 32610; port++ {
+			rules += "-A PREROUTING -i eth0 -p tcp -m tcp --dport " + strconv.Itoa(port) + " -j DNAT --to-destination 192.168.66.201:" + strconv.Itoa(port) + "\n"
+		}
+		_, _, err := loadBalancerPort(rules, "192.168.66.201:80")
+		Expect(err).To(MatchError("all 10 ports reserved for LoadBalancer IPs are in use"))
+	})
+
+	Describe("LoadBalancer targets", func() {
+		var k8sClient k8s.K8sDynamicClient
+
+		BeforeEach(func() {
+			k8sClient = k8s.NewTestClient()
+			service := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"type": "LoadBalancer",
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(53), "protocol": "UDP"},
+						map[string]interface{}{"port": int64(80)},
+						map[string]interface{}{"port": int64(443), "protocol": "TCP"},
+					},
+				},
+				"status": map[string]interface{}{
+					"loadBalancer": map[string]interface{}{
+						"ingress": []interface{}{map[string]interface{}{"ip": "192.168.66.201"}},
+					},
+				},
+			}}
+			Expect(k8sClient.Apply(service)).To(Succeed())
+		})
+
+		DescribeTable("should resolve the LoadBalancer IP and port", func(servicePort int64, expected string) {
+			Expect(loadBalancerTarget(k8sClient, "default", "web", servicePort)).To(Equal(expected))
+		},
+			Entry("first TCP port", int64(0), "192.168.66.201:80"),
+			Entry("given port", int64(443), "192.168.66.201:443"),
+		)
+
+		It("should refuse ports the service does not have", func() {
+			_, err := loadBalancerTarget(k8sClient, "default", "web", 53)
+			Expect(err).To(MatchError("service default/web has no TCP port 53"))
+		})
+	})
+})
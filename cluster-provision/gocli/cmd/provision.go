@@ -24,6 +24,7 @@ import (
 
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/cmd/utils"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/docker"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
@@ -353,11 +354,12 @@ func provisionCluster(cmd *cobra.Command, args []string) (retErr error) {
 	}
 
 	logrus.Infof("Commiting the node as %s", target)
+	// gocli run takes the Kubernetes version of the provider from the label, before the API server is up
 	_, err = cli.ContainerCommit(ctx, node.ID, container.CommitOptions{
 		Reference: target,
 		Comment:   "PROVISION SUCCEEDED",
 		Author:    "gocli",
-		Changes:   nil,
+		Changes:   []string{fmt.Sprintf("LABEL %s=%s", k8s.VersionLabel, version)},
 		Pause:     false,
 		Config:    nil,
 	})
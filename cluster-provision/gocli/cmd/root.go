@@ -29,6 +29,8 @@ func NewRootCommand() *cobra.Command {
 		NewRunCommand(),
 		NewSSHCommand(),
 		NewSCPCommand(),
+		NewAddonCommand(),
+		NewCACommand(),
 		NewProvisionManagerCommand(),
 	)
 
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
@@ -22,6 +24,7 @@ import (
 	"github.com/docker/go-connections/nat"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/cmd/nodesconfig"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/cmd/utils"
@@ -32,11 +35,21 @@ import (
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/aaq"
 	bindvfio "kubevirt.io/kubevirtci/cluster-provision/gocli/opts/bind-vfio"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cdi"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/certmanager"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cnao"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cni"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/csihostpath"
 	dockerproxy "kubevirt.io/kubevirtci/cluster-provision/gocli/opts/docker-proxy"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/dra"
 	etcdinmemory "kubevirt.io/kubevirtci/cluster-provision/gocli/opts/etcd"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/ingress"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/istio"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/ksm"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/kubevirt"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/kwok"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/localaddon"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/logging"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/metallb"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/multus"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/network_resources_injector"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/nfscsi"
@@ -47,8 +60,12 @@ import (
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/realtime"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/rookceph"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/rootkey"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/snapshotcontroller"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/sriov"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/swap"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/velero"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/vsock"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/whereabouts"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 
@@ -85,6 +102,20 @@ EOF
 
 	secondaryNicRootPortBaseSlot  = 4
 	secondaryNicRootPortBaseChass = 10
+	// the first SR-IOV NIC is attached by the provider on slot 3 of chassis 3, the others get a chassis of their own
+	sriovNicRootPortBaseChass = 40
+	maxSriovNics              = 8
+
+	nfsExportOptions = "fsid=0,rw,sync,insecure,no_root_squash,no_subtree_check,nohide"
+	// addNfsSecondExport waits for the export of NFS_DIR, the server would drop the second one when it exports
+	// NFS_DIR after it
+	addNfsSecondExport = `for i in $(seq 1 60); do
+	if exportfs | grep -q '^/data/nfs[[:space:]]'; then
+		exec exportfs -o fsid=1,rw,sync,insecure,no_root_squash,no_subtree_check '*:/data/nfs/migration'
+	fi
+	sleep 1
+done
+exit 1`
 )
 
 // Required PCI ids are hardcoded in KubeVirt e2e tests:
@@ -100,6 +131,7 @@ var nvmeDisks []string
 var scsiDisks []string
 var usbDisks []string
 var sharedDisks []string
+var addonDirs []string
 var sshClient libssh.Client
 
 // NewRunCommand returns command that runs given cluster
@@ -117,6 +149,7 @@ func NewRunCommand() *cobra.Command {
 	run.Flags().UintP("cpu", "c", 2, "number of cpu cores per node")
 	run.Flags().UintP("secondary-nics", "", 0, "number of secondary nics to add")
 	run.Flags().Bool("enable-secondary-nic-bridges", false, "create bridge devices for secondary NICs")
+	run.Flags().Uint("sriov-nics", 1, "number of emulated SR-IOV NICs (igb) of every node, the first one is always attached")
 	run.Flags().String("qemu-args", "", "additional qemu args to pass through to the nodes")
 	run.Flags().String("kernel-args", "", "additional kernel args to pass through to the nodes")
 	run.Flags().BoolP("background", "b", true, "go to background after nodes are up")
@@ -132,22 +165,46 @@ func NewRunCommand() *cobra.Command {
 	run.Flags().Uint("prometheus-port", 0, "port on localhost for prometheus server")
 	run.Flags().Uint("grafana-port", 0, "port on localhost for grafana server")
 	run.Flags().Uint("dns-port", 0, "port on localhost for dns server")
+	run.Flags().Uint("loki-port", 0, "port on localhost for the Loki API")
+	run.Flags().Uint("minio-port", 0, "port on localhost for the MinIO console")
 	run.Flags().String("nfs-data", "", "path to data which should be exposed via nfs to the nodes")
+	run.Flags().String("nfs-export-options", nfsExportOptions, "export options of the nfs server")
+	run.Flags().String("nfs-tmpfs-size", "", "keeps the nfs exports in memory, in tmpfs volumes of this size, instead of on disk when --enable-nfs-csi is used without --nfs-data")
+	run.Flags().Bool("nfs-second-export", false, "exports a second volume as /migration from the nfs server and creates the nfs-csi-migration StorageClass for it")
 	run.Flags().Bool("enable-ceph", false, "enables dynamic storage provisioning using Ceph")
+	run.Flags().Uint("ceph-osds", 1, "number of block devices every node is started with for Ceph OSDs")
+	run.Flags().String("ceph-osd-size", rookceph.DefaultOSDSize, "size of the block devices for Ceph OSDs")
+	run.Flags().Int("ceph-replicas", 1, "replication size of the Ceph pools, at most the number of OSDs of the cluster")
+	run.Flags().Bool("ceph-filesystem", false, "creates a CephFS filesystem with the rook-cephfs StorageClass for RWX volumes")
+	run.Flags().Bool("ceph-object-store", false, "creates a Ceph object store with the rook-ceph-bucket StorageClass for ObjectBucketClaims")
+	run.Flags().Bool("ceph-default-storage-class", true, "makes rook-ceph-block the default StorageClass")
 	run.Flags().Bool("enable-istio", false, "deploys Istio service mesh")
 	run.Flags().Bool("reverse", false, "reverse node setup order")
 	run.Flags().Bool("enable-cnao", false, "enable network extensions with istio")
 	run.Flags().Bool("skip-cnao-cr", false, "skip deploying cnao custom resource. if true, only cnao CRDS will be deployed")
 	run.Flags().Bool("deploy-dnc", false, "deploy the dynamic networks controller with CNAO")
 	run.Flags().Bool("deploy-multus", false, "deploy multus")
+	run.Flags().Bool("deploy-whereabouts", false, "deploys the whereabouts IPAM plugin, implies --deploy-multus")
+	run.Flags().Bool("whereabouts-secondary-networks", false, "creates a NetworkAttachmentDefinition with a whereabouts range for the bridge of each secondary nic")
 	run.Flags().Bool("deploy-cdi", false, "deploy cdi")
 	run.Flags().String("cdi-version", "", "cdi version")
 	run.Flags().String("aaq-version", "", "aaq version")
 	run.Flags().Bool("deploy-aaq", false, "deploy aaq")
+	run.Flags().Bool("deploy-kubevirt", false, "deploy kubevirt")
+	run.Flags().String("kubevirt-version", "", "kubevirt release to deploy, defaults to the latest stable release")
+	run.Flags().String("kubevirt-manifests", "", "directory with the kubevirt operator manifests to deploy instead of a release")
+	run.Flags().StringSlice("kubevirt-feature-gates", []string{}, "kubevirt feature gates to enable")
+	run.Flags().String("kubevirt-config", "", "yaml which is merged into spec.configuration of the kubevirt CR")
+	run.Flags().String("kubevirt-config-file", "", "file with yaml which is merged into spec.configuration of the kubevirt CR, instead of --kubevirt-config")
+	run.Flags().Bool("kubevirt-auto-emulation", false, "enable software emulation in kubevirt if the nodes lack nested virtualization")
 	run.Flags().Bool("enable-nfs-csi", false, "deploys nfs csi dynamic storage")
+	run.Flags().Bool("enable-csi-hostpath", false, "deploys the CSI hostpath driver and the snapshot controller, for storage with snapshots and clones")
+	run.Flags().Bool("csi-hostpath-default", false, "makes the StorageClass and VolumeSnapshotClass of the CSI hostpath driver the default ones")
 	run.Flags().Bool("enable-prometheus", false, "deploys Prometheus operator")
 	run.Flags().Bool("enable-prometheus-alertmanager", false, "deploys Prometheus alertmanager")
 	run.Flags().Bool("enable-grafana", false, "deploys Grafana")
+	run.Flags().String("prometheus-extra", "", "directory with PrometheusRules, ServiceMonitors and PodMonitors in .yaml files and Grafana dashboards in .json files to deploy with Prometheus, it can be synced again with gocli addon sync prometheus")
+	run.Flags().Bool("enable-logging", false, "deploys Loki and promtail, which keep the logs of all pods, Grafana gets a Loki datasource")
 	run.Flags().Bool("enable-ksm", false, "enables kernel memory same page merging")
 	run.Flags().Uint("ksm-page-count", 10, "number of pages to scan per time in ksm")
 	run.Flags().Uint("ksm-scan-interval", 20, "sleep interval in milliseconds for ksm")
@@ -169,16 +226,41 @@ func NewRunCommand() *cobra.Command {
 	run.Flags().Bool("enable-realtime-scheduler", false, "configures the kernel to allow unlimited runtime for processes that require realtime scheduling")
 	run.Flags().Bool("enable-fips", false, "enables FIPS")
 	run.Flags().Bool("enable-psa", false, "Pod Security Admission")
+	run.Flags().String("psa-enforce", psa.DefaultLevel, "Pod Security Standard level enforced with --enable-psa, one of privileged, baseline or restricted")
+	run.Flags().String("psa-audit", psa.DefaultLevel, "Pod Security Standard level audited with --enable-psa")
+	run.Flags().String("psa-warn", psa.DefaultLevel, "Pod Security Standard level warned about with --enable-psa")
+	run.Flags().StringSlice("psa-exempt-namespaces", psa.DefaultExemptNamespaces, "namespaces exempt from Pod Security Admission with --enable-psa, replaces the default ones")
+	run.Flags().String("psa-config", "", "AdmissionConfiguration file kube-apiserver is started with on --enable-psa, instead of the one rendered from the --psa-* flags")
 	run.Flags().Bool("single-stack", false, "enable single stack IPv6")
+	run.Flags().String("cni", cni.DefaultName, "CNI of the cluster, one of "+strings.Join(cni.Names(), ", "))
 	run.Flags().Bool("flannel", false, "use flannel CNI instead of default CNI")
+	run.Flags().MarkDeprecated("flannel", "use --cni flannel instead")
 	run.Flags().Bool("no-etcd-fsync", false, "unsafe: disable fsyncs in etcd")
 	run.Flags().Bool("enable-audit", false, "enable k8s audit for all metadata events")
 	run.Flags().StringArrayVar(&usbDisks, "usb", []string{}, "size of the emulate USB disk to pass to the node")
 	run.Flags().StringArrayVar(&sharedDisks, "shared-block-device", []string{}, "size of block device to share between all nodes")
 	run.Flags().Bool("deploy-network-resources-injector", false, "deploys Network Resources Injector")
+	run.Flags().Bool("enable-metallb", false, "deploys MetalLB to assign IPs to services of type LoadBalancer")
+	run.Flags().String("metallb-address-pool", metallb.DefaultAddressPool, "range or CIDR MetalLB assigns LoadBalancer IPs from")
+	run.Flags().StringSlice("metallb-interfaces", []string{}, "node interfaces MetalLB announces the LoadBalancer IPs on, e.g. br1 for a secondary network, defaults to all")
+	run.Flags().Bool("deploy-ingress", false, "deploys ingress-nginx on the http and https ports and resolves a wildcard domain to it on the dns port")
+	run.Flags().String("ingress-domain", ingress.DefaultDomain, "wildcard domain resolved to the ingress")
+	run.Flags().String("ingress-address", ingress.DefaultAddress, "address the wildcard domain resolves to")
+	run.Flags().Bool("deploy-velero", false, "deploys Velero with its CSI support and a backup storage location in MinIO")
+	run.Flags().String("velero-minio", velero.MinIOSidecar, "where MinIO runs, sidecar for a container next to the nodes or in-cluster")
+	run.Flags().Bool("velero-kubevirt-plugin", false, "adds the KubeVirt Velero plugin to Velero")
+	run.Flags().Bool("deploy-cert-manager", false, "deploys cert-manager with a CA issuer, the CA can be exported with gocli ca")
+	run.Flags().Int("kwok-nodes", 0, "deploys the kwok-controller and registers the given number of fake nodes")
+	run.Flags().String("kwok-node-template", "", "file with a Node manifest the fake nodes are created from, sets their capacity, labels and taints")
+	run.Flags().Bool("enable-dra", false, "deploys the DRA example driver, which advertises emulated devices on the nodes, and publishes the PCI devices bound to vfio-pci")
+	run.Flags().Int("dra-devices", dra.DefaultDevices, "number of emulated devices the DRA example driver advertises on every node")
+	run.Flags().Bool("deploy-sriov", false, "creates VFs on the emulated SR-IOV NICs and deploys the SR-IOV CNI and device plugin")
+	run.Flags().Int("sriov-vfs", sriov.DefaultVFs, "number of VFs created on every emulated SR-IOV NIC")
+	run.Flags().StringArrayVar(&addonDirs, "addon", []string{}, "directory of an add-on with an addon.yaml to deploy, can be given multiple times")
 	run.Flags().String("vsock-child-ns-mode", "", "vsock child namespace mode (global or local)")
 	run.Flags().String("topology-manager-policy", "", "kubelet topology manager policy (e.g. single-numa-node)")
 	run.Flags().String("reserved-system-cpus", "", "kubelet reserved system cpuset (e.g. 4 or 4-5)")
+	run.Flags().String("run-dir", "", "directory to store run artifacts in, e.g. the per node ssh transcripts")
 
 	return run
 }
@@ -248,6 +330,12 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 	if err := utils.AppendUDPIfExplicit(portMap, utils.PortDNS, cmd.Flags(), "dns-port"); err != nil {
 		return err
 	}
+	if err := utils.AppendTCPIfExplicit(portMap, utils.PortLoki, cmd.Flags(), "loki-port"); err != nil {
+		return err
+	}
+	if err := utils.AppendTCPIfExplicit(portMap, utils.PortMinIOConsole, cmd.Flags(), "minio-port"); err != nil {
+		return err
+	}
 
 	qemuArgs, err := cmd.Flags().GetString("qemu-args")
 	if err != nil {
@@ -278,6 +366,14 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
+	sriovNics, err := cmd.Flags().GetUint("sriov-nics")
+	if err != nil {
+		return err
+	}
+	if sriovNics < 1 || sriovNics > maxSriovNics {
+		return fmt.Errorf("--sriov-nics has to be between 1 and %d", maxSriovNics)
+	}
+
 	topologyManagerPolicy, err := cmd.Flags().GetString("topology-manager-policy")
 	if err != nil {
 		return err
@@ -302,11 +398,77 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
+	cephOSDs, err := cmd.Flags().GetUint("ceph-osds")
+	if err != nil {
+		return err
+	}
+	if cephOSDs < 1 {
+		return fmt.Errorf("--ceph-osds has to be at least 1")
+	}
+
+	cephOSDSizeFlag, err := cmd.Flags().GetString("ceph-osd-size")
+	if err != nil {
+		return err
+	}
+	cephOSDSize, err := resource.ParseQuantity(cephOSDSizeFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --ceph-osd-size %s: %w", cephOSDSizeFlag, err)
+	}
+
+	cephReplicas, err := cmd.Flags().GetInt("ceph-replicas")
+	if err != nil {
+		return err
+	}
+	if cephReplicas < 1 || cephReplicas > int(nodes*cephOSDs) {
+		return fmt.Errorf("--ceph-replicas has to be between 1 and the %d OSDs of the cluster", nodes*cephOSDs)
+	}
+
+	cephFilesystem, err := cmd.Flags().GetBool("ceph-filesystem")
+	if err != nil {
+		return err
+	}
+
+	cephObjectStore, err := cmd.Flags().GetBool("ceph-object-store")
+	if err != nil {
+		return err
+	}
+
+	cephDefaultStorageClass, err := cmd.Flags().GetBool("ceph-default-storage-class")
+	if err != nil {
+		return err
+	}
+
 	nfsCsiEnabled, err := cmd.Flags().GetBool("enable-nfs-csi")
 	if err != nil {
 		return err
 	}
 
+	nfsExportOptionsFlag, err := cmd.Flags().GetString("nfs-export-options")
+	if err != nil {
+		return err
+	}
+
+	nfsTmpfsSizeFlag, err := cmd.Flags().GetString("nfs-tmpfs-size")
+	if err != nil {
+		return err
+	}
+	var nfsTmpfsSize int64
+	if nfsTmpfsSizeFlag != "" {
+		if nfsData != "" {
+			return fmt.Errorf("--nfs-tmpfs-size can not be used with --nfs-data")
+		}
+		size, err := resource.ParseQuantity(nfsTmpfsSizeFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --nfs-tmpfs-size %s: %w", nfsTmpfsSizeFlag, err)
+		}
+		nfsTmpfsSize = size.Value()
+	}
+
+	nfsSecondExport, err := cmd.Flags().GetBool("nfs-second-export")
+	if err != nil {
+		return err
+	}
+
 	istioEnabled, err := cmd.Flags().GetBool("enable-istio")
 	if err != nil {
 		return err
@@ -327,6 +489,27 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
+	loggingEnabled, err := cmd.Flags().GetBool("enable-logging")
+	if err != nil {
+		return err
+	}
+
+	prometheusExtra, err := cmd.Flags().GetString("prometheus-extra")
+	if err != nil {
+		return err
+	}
+	if prometheusExtra != "" {
+		if !prometheusEnabled {
+			return fmt.Errorf("--prometheus-extra requires --enable-prometheus")
+		}
+		// the directory is loaded once before the cluster is created, so mistakes in it fail fast
+		extra, err := prometheus.LoadExtra(prometheusExtra)
+		if err != nil {
+			return err
+		}
+		prometheusExtra = extra.Dir()
+	}
+
 	cluster := args[0]
 
 	background, err := cmd.Flags().GetBool("background")
@@ -381,14 +564,32 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 	if err != nil {
 		return err
 	}
+	psaConfig, err := admissionConfig(cmd.Flags(), psaEnabled)
+	if err != nil {
+		return err
+	}
 	singleStack, err := cmd.Flags().GetBool("single-stack")
 	if err != nil {
 		return err
 	}
+	cniName, err := cmd.Flags().GetString("cni")
+	if err != nil {
+		return err
+	}
 	flannel, err := cmd.Flags().GetBool("flannel")
 	if err != nil {
 		return err
 	}
+	if flannel {
+		if cmd.Flags().Changed("cni") && cniName != "flannel" {
+			return fmt.Errorf("--flannel conflicts with --cni %s", cniName)
+		}
+		cniName = "flannel"
+	}
+	selectedCNI, err := cni.Get(cniName)
+	if err != nil {
+		return err
+	}
 	noEtcdFsync, err := cmd.Flags().GetBool("no-etcd-fsync")
 	if err != nil {
 		return err
@@ -434,11 +635,65 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
+	deployKubevirt, err := cmd.Flags().GetBool("deploy-kubevirt")
+	if err != nil {
+		return err
+	}
+
+	kubevirtVersion, err := cmd.Flags().GetString("kubevirt-version")
+	if err != nil {
+		return err
+	}
+
+	kubevirtManifests, err := cmd.Flags().GetString("kubevirt-manifests")
+	if err != nil {
+		return err
+	}
+
+	if kubevirtVersion != "" && kubevirtManifests != "" {
+		return fmt.Errorf("only one of --kubevirt-version and --kubevirt-manifests can be given")
+	}
+
+	kubevirtFeatureGates, err := cmd.Flags().GetStringSlice("kubevirt-feature-gates")
+	if err != nil {
+		return err
+	}
+
+	kubevirtConfig, err := kubevirtConfiguration(cmd.Flags())
+	if err != nil {
+		return err
+	}
+
+	kubevirtAutoEmulation, err := cmd.Flags().GetBool("kubevirt-auto-emulation")
+	if err != nil {
+		return err
+	}
+
 	deployMultus, err := cmd.Flags().GetBool("deploy-multus")
 	if err != nil {
 		return err
 	}
 
+	deployWhereabouts, err := cmd.Flags().GetBool("deploy-whereabouts")
+	if err != nil {
+		return err
+	}
+
+	whereaboutsSecondaryNetworks, err := cmd.Flags().GetBool("whereabouts-secondary-networks")
+	if err != nil {
+		return err
+	}
+	if whereaboutsSecondaryNetworks && !deployWhereabouts {
+		return fmt.Errorf("--whereabouts-secondary-networks requires --deploy-whereabouts")
+	}
+	whereaboutsNetworks := uint(0)
+	if whereaboutsSecondaryNetworks {
+		whereaboutsNetworks = secondaryNics
+	}
+
+	// whereabouts plugs into multus networks
+	deployMultus = deployMultus || deployWhereabouts
+
 	enableSwap, err := cmd.Flags().GetBool("enable-swap")
 	if err != nil {
 		return err
@@ -484,6 +739,148 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
+	metallbEnabled, err := cmd.Flags().GetBool("enable-metallb")
+	if err != nil {
+		return err
+	}
+
+	metallbAddressPool, err := cmd.Flags().GetString("metallb-address-pool")
+	if err != nil {
+		return err
+	}
+
+	metallbInterfaces, err := cmd.Flags().GetStringSlice("metallb-interfaces")
+	if err != nil {
+		return err
+	}
+
+	deployIngress, err := cmd.Flags().GetBool("deploy-ingress")
+	if err != nil {
+		return err
+	}
+
+	ingressDomain, err := cmd.Flags().GetString("ingress-domain")
+	if err != nil {
+		return err
+	}
+
+	ingressAddress, err := cmd.Flags().GetString("ingress-address")
+	if err != nil {
+		return err
+	}
+
+	deployCertManager, err := cmd.Flags().GetBool("deploy-cert-manager")
+	if err != nil {
+		return err
+	}
+
+	kwokNodes, err := cmd.Flags().GetInt("kwok-nodes")
+	if err != nil {
+		return err
+	}
+	if kwokNodes < 0 {
+		return fmt.Errorf("--kwok-nodes has to be at least 0")
+	}
+
+	kwokNodeTemplateFile, err := cmd.Flags().GetString("kwok-node-template")
+	if err != nil {
+		return err
+	}
+	kwokNodeTemplate := ""
+	if kwokNodeTemplateFile != "" {
+		template, err := os.ReadFile(kwokNodeTemplateFile)
+		if err != nil {
+			return err
+		}
+		kwokNodeTemplate = string(template)
+	}
+	if _, err := kwok.ParseNodeTemplate([]byte(kwokNodeTemplate)); err != nil {
+		return err
+	}
+
+	draEnabled, err := cmd.Flags().GetBool("enable-dra")
+	if err != nil {
+		return err
+	}
+
+	draDevices, err := cmd.Flags().GetInt("dra-devices")
+	if err != nil {
+		return err
+	}
+	if draDevices < 1 {
+		return fmt.Errorf("--dra-devices has to be at least 1")
+	}
+
+	deploySriov, err := cmd.Flags().GetBool("deploy-sriov")
+	if err != nil {
+		return err
+	}
+
+	sriovVFs, err := cmd.Flags().GetInt("sriov-vfs")
+	if err != nil {
+		return err
+	}
+	if sriovVFs < 1 || sriovVFs > sriov.DefaultVFs {
+		return fmt.Errorf("--sriov-vfs has to be between 1 and %d", sriov.DefaultVFs)
+	}
+	if deploySriov && runtime.GOARCH == "s390x" {
+		return fmt.Errorf("--deploy-sriov is not supported on s390x, the nodes have no emulated SR-IOV NICs")
+	}
+	if !deploySriov {
+		sriovVFs = 0
+	}
+
+	csiHostpathEnabled, err := cmd.Flags().GetBool("enable-csi-hostpath")
+	if err != nil {
+		return err
+	}
+
+	csiHostpathDefault, err := cmd.Flags().GetBool("csi-hostpath-default")
+	if err != nil {
+		return err
+	}
+	if csiHostpathDefault && !csiHostpathEnabled {
+		return fmt.Errorf("--csi-hostpath-default requires --enable-csi-hostpath")
+	}
+	if csiHostpathDefault && cephEnabled && cephDefaultStorageClass {
+		return fmt.Errorf("--csi-hostpath-default conflicts with --enable-ceph, unless --ceph-default-storage-class=false is set")
+	}
+
+	deployVelero, err := cmd.Flags().GetBool("deploy-velero")
+	if err != nil {
+		return err
+	}
+
+	veleroMinIO, err := cmd.Flags().GetString("velero-minio")
+	if err != nil {
+		return err
+	}
+	if veleroMinIO != velero.MinIOSidecar && veleroMinIO != velero.MinIOInCluster {
+		return fmt.Errorf("--velero-minio has to be %s or %s", velero.MinIOSidecar, velero.MinIOInCluster)
+	}
+
+	veleroKubeVirtPlugin, err := cmd.Flags().GetBool("velero-kubevirt-plugin")
+	if err != nil {
+		return err
+	}
+
+	// add-on directories are loaded once before the cluster is created, so mistakes in them fail fast
+	for _, dir := range addonDirs {
+		if _, err := loadLocalAddon(dir); err != nil {
+			return err
+		}
+	}
+
+	runDir, err := cmd.Flags().GetString("run-dir")
+	if err != nil {
+		return err
+	}
+	if runDir != "" {
+		if err := libssh.EnableTranscript(filepath.Join(runDir, "transcripts")); err != nil {
+			return err
+		}
+	}
+
 	cli, err = client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
 		return err
@@ -533,6 +930,27 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		}
 	}
 
+	// the nodes are provisioned before the API server is up, so the version is taken from the image
+	clusterImageInfo, err := cli.ImageInspect(ctx, clusterImage)
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{}
+	if clusterImageInfo.Config != nil {
+		labels = clusterImageInfo.Config.Labels
+	}
+	providerVersion, err := k8s.ProviderVersion(labels, cluster)
+	if err != nil {
+		return err
+	}
+
+	if metallbEnabled && !randomPorts {
+		// gocli ports loadbalancer needs the reserved ports to be published, pick free host ports for them
+		for port := utils.PortLoadBalancer; port < utils.PortLoadBalancer+utils.LoadBalancerPortCount; port++ {
+			portMap[utils.TCPPortOrDie(port)] = []nat.PortBinding{{HostIP: "127.0.0.1"}}
+		}
+	}
+
 	var dnsmasq *container.CreateResponse
 	for i := 0; i <= 3; i++ {
 		if i == 3 {
@@ -542,10 +960,12 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		dnsmasq, err = containers2.DNSMasq(cli, ctx, &containers2.DNSMasqOptions{
 			ClusterImage:       clusterImage,
 			SecondaryNicsCount: secondaryNics,
+			SriovNicsCount:     sriovNics,
 			RandomPorts:        randomPorts,
 			PortMap:            portMap,
 			Prefix:             prefix,
 			NodeCount:          nodes,
+			LoadBalancerPorts:  metallbEnabled,
 		})
 		if err != nil {
 			return err
@@ -600,11 +1020,44 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
-	if nfsData != "" {
-		nfsData, err := filepath.Abs(nfsData)
-		if err != nil {
-			return err
+	// --enable-nfs-csi brings up the nfs server on its own, its export is a volume of the cluster then
+	if nfsData != "" || nfsCsiEnabled {
+		nfsMount := mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: prefix + "-nfs",
+			Target: "/data/nfs",
+		}
+		if nfsData != "" {
+			nfsData, err := filepath.Abs(nfsData)
+			if err != nil {
+				return err
+			}
+			nfsMount.Type = mount.TypeBind
+			nfsMount.Source = nfsData
+		} else {
+			nfsVolume, err := cli.VolumeCreate(ctx, nfsVolumeOptions(nfsMount.Source, nfsTmpfsSize))
+			if err != nil {
+				return err
+			}
+			volumes <- nfsVolume.Name
+		}
+		nfsMounts := []mount.Mount{nfsMount}
+
+		// The second export is a volume of its own below the root of the first one, nfs v4 clients only see
+		// exports inside the fsid=0 root
+		if nfsSecondExport {
+			migrationVolume, err := cli.VolumeCreate(ctx, nfsVolumeOptions(prefix+"-nfs-migration", nfsTmpfsSize))
+			if err != nil {
+				return err
+			}
+			volumes <- migrationVolume.Name
+			nfsMounts = append(nfsMounts, mount.Mount{
+				Type:   mount.TypeVolume,
+				Source: migrationVolume.Name,
+				Target: "/data/nfs/migration",
+			})
 		}
+
 		// Pull the nfs image
 		err = docker.ImagePull(cli, ctx, utils.NFSServerImage, image.PullOptions{})
 		if err != nil {
@@ -616,16 +1069,10 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 			Image: utils.NFSServerImage,
 			Env: []string{
 				"NFS_DIR=/data/nfs",
-				"NFS_OPTION=fsid=0,rw,sync,insecure,no_root_squash,no_subtree_check,nohide",
+				"NFS_OPTION=" + nfsExportOptionsFlag,
 			},
 		}, &container.HostConfig{
-			Mounts: []mount.Mount{
-				{
-					Type:   mount.TypeBind,
-					Source: nfsData,
-					Target: "/data/nfs",
-				},
-			},
+			Mounts:      nfsMounts,
 			Privileged:  true,
 			NetworkMode: container.NetworkMode("container:" + dnsmasq.ID),
 		}, nil, nil, prefix+"-nfs")
@@ -636,6 +1083,56 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		if err := cli.ContainerStart(ctx, nfsServer.ID, container.StartOptions{}); err != nil {
 			return err
 		}
+
+		// The image only exports NFS_DIR, the second export is added once the server exported the first one
+		if nfsSecondExport {
+			success, err := docker.Exec(cli, nfsServer.ID, []string{"/bin/bash", "-c", addNfsSecondExport}, os.Stdout)
+			if err != nil {
+				return err
+			}
+			if !success {
+				return fmt.Errorf("failed to export /data/nfs/migration from the nfs server")
+			}
+		}
+	}
+
+	// MinIO runs next to the registry, Velero reaches it on the address of dnsmasq
+	if deployVelero && veleroMinIO == velero.MinIOSidecar {
+		minioVolume, err := cli.VolumeCreate(ctx, volume.CreateOptions{Name: prefix + "-minio"})
+		if err != nil {
+			return err
+		}
+		volumes <- minioVolume.Name
+
+		err = docker.ImagePull(cli, ctx, utils.MinIOImage, image.PullOptions{})
+		if err != nil {
+			return err
+		}
+
+		minio, err := cli.ContainerCreate(ctx, &container.Config{
+			Image: utils.MinIOImage,
+			Cmd:   []string{"server", "/data", "--console-address", fmt.Sprintf(":%d", utils.PortMinIOConsole)},
+			Env: []string{
+				"MINIO_ROOT_USER=" + velero.MinIOUser,
+				"MINIO_ROOT_PASSWORD=" + velero.MinIOPassword,
+			},
+		}, &container.HostConfig{
+			Mounts: []mount.Mount{
+				{
+					Type:   mount.TypeVolume,
+					Source: minioVolume.Name,
+					Target: "/data",
+				},
+			},
+			NetworkMode: container.NetworkMode("container:" + dnsmasq.ID),
+		}, nil, nil, prefix+"-minio")
+		if err != nil {
+			return err
+		}
+		containers <- minio.ID
+		if err := cli.ContainerStart(ctx, minio.ID, container.StartOptions{}); err != nil {
+			return err
+		}
 	}
 
 	sharedVolumeName := prefix + "-shared"
@@ -713,6 +1210,25 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 			}
 		}
 
+		// the provider attaches the first SR-IOV NIC to the tap-sriov tap of the node, the others are attached to the
+		// taps dnsmasq creates for them on the same bridge
+		if qemuNetDevice != QEMU_DEVICE_S390X {
+			for i := 1; i < int(sriovNics); i++ {
+				nodeQemuArgs = fmt.Sprintf("%s -device pcie-root-port,id=sriovrp%d,slot=3,chassis=%d,bus=sriovpxb -device igb,id=igb%d,bus=sriovrp%d,netdev=sriovnet%d,mac=52:55:00:d1:%02x:%s -netdev tap,id=sriovnet%d,ifname=tap-sriov%s-%d,script=no,downscript=no",
+					nodeQemuArgs,
+					i,
+					sriovNicRootPortBaseChass+i,
+					i,
+					i,
+					i,
+					0x57+i,
+					nodeNum,
+					i,
+					nodeNum,
+					i)
+			}
+		}
+
 		// assign a GPU to one node
 		var deviceMappings []container.DeviceMapping
 		if gpuAddress != "" && x == int(nodes)-1 {
@@ -787,6 +1303,16 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 			}
 		}
 
+		blockDev := ""
+		if cephEnabled {
+			blockDev = fmt.Sprintf("--block-device /var/run/disk/blockdev.qcow2 --block-device-size %d", cephOSDSize.Value())
+			// the provider creates the additional OSD disks next to the block device
+			for i := 1; i < int(cephOSDs); i++ {
+				blockDev = fmt.Sprintf("%s --extra-block-device-size %d", blockDev, cephOSDSize.Value())
+				nodeQemuArgs = fmt.Sprintf("%s -drive format=qcow2,file=/var/run/disk/blockdev-%d.qcow2,if=none,id=osddisk%d,cache=unsafe -device virtio-blk,drive=osddisk%d%s", nodeQemuArgs, i, i, i, pcieBus)
+			}
+		}
+
 		additionalArgs := []string{}
 		if len(nodeQemuArgs) > 0 {
 			additionalArgs = append(additionalArgs, "--qemu-args", shellescape.Quote(nodeQemuArgs))
@@ -808,11 +1334,6 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 			kernelArgs += " fips=1"
 		}
 
-		blockDev := ""
-		if cephEnabled {
-			blockDev = "--block-device /var/run/disk/blockdev.qcow2 --block-device-size 32212254720"
-		}
-
 		kernelArgs = strings.TrimSpace(kernelArgs)
 		if kernelArgs != "" {
 			additionalArgs = append(additionalArgs, "--additional-kernel-args", shellescape.Quote(kernelArgs))
@@ -901,12 +1422,13 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 			nodesconfig.WithEtcdInMemory(runEtcdOnMemory),
 			nodesconfig.WithEtcdSize(etcdDataMountSize),
 			nodesconfig.WithSingleStack(singleStack),
-			nodesconfig.WithFlannel(flannel),
+			nodesconfig.WithCNI(cniName),
 			nodesconfig.WithNoEtcdFsync(noEtcdFsync),
 			nodesconfig.WithEnableAudit(enableAudit),
 			nodesconfig.WithGpuAddress(gpuAddress),
 			nodesconfig.WithRealtime(realtimeSchedulingEnabled),
 			nodesconfig.WithPSA(psaEnabled),
+			nodesconfig.WithPSAConfig(psaConfig),
 			nodesconfig.WithKsm(enableKsm),
 			nodesconfig.WithKsmPageCount(int(ksmPageCount)),
 			nodesconfig.WithKsmScanInterval(int(ksmScanInterval)),
@@ -918,9 +1440,10 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 			nodesconfig.WithVsockChildNsMode(vsockChildNsMode),
 			nodesconfig.WithTopologyManagerPolicy(topologyManagerPolicy),
 			nodesconfig.WithReservedSystemCPUs(reservedSystemCPUs),
+			nodesconfig.WithSriovVFs(sriovVFs),
 		}
 
-		n := nodesconfig.NewNodeLinuxConfig(x+1, prefix, linuxConfigFuncs)
+		n := nodesconfig.NewNodeLinuxConfig(x+1, prefix, providerVersion, linuxConfigFuncs)
 
 		if err = provisionNode(sshClient, n); err != nil {
 			return err
@@ -939,20 +1462,56 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 
 	k8sConfs := []nodesconfig.K8sConfigFunc{
 		nodesconfig.WithCeph(cephEnabled),
+		nodesconfig.WithCephConfig(rookceph.Config{
+			Replicas:            cephReplicas,
+			Filesystem:          cephFilesystem,
+			ObjectStore:         cephObjectStore,
+			DefaultStorageClass: cephDefaultStorageClass,
+		}),
 		nodesconfig.WithPrometheus(prometheusEnabled),
 		nodesconfig.WithAlertmanager(prometheusAlertmanagerEnabled),
 		nodesconfig.WithGrafana(grafanaEnabled),
+		nodesconfig.WithLogging(loggingEnabled),
+		nodesconfig.WithPrometheusExtra(prometheusExtra),
 		nodesconfig.WithIstio(istioEnabled),
 		nodesconfig.WithNfsCsi(nfsCsiEnabled),
+		nodesconfig.WithNfsSecondExport(nfsSecondExport),
 		nodesconfig.WithCnao(cnaoEnabled),
 		nodesconfig.WithCNAOSkipCR(cnaoSkipCR),
 		nodesconfig.WithDNC(deployDNC),
 		nodesconfig.WithMultus(deployMultus),
+		nodesconfig.WithWhereabouts(deployWhereabouts),
+		nodesconfig.WithWhereaboutsNetworks(whereaboutsNetworks),
 		nodesconfig.WithCdi(deployCdi),
 		nodesconfig.WithCdiVersion(cdiVersion),
 		nodesconfig.WithAAQ(deployAaq),
 		nodesconfig.WithAAQVersion(aaqVersion),
 		nodesconfig.WithNetworkResourcesInjector(deployNetworkResourcesInjector),
+		nodesconfig.WithAddons(addonDirs),
+		nodesconfig.WithKubeVirt(deployKubevirt),
+		nodesconfig.WithKubeVirtVersion(kubevirtVersion),
+		nodesconfig.WithKubeVirtManifests(kubevirtManifests),
+		nodesconfig.WithKubeVirtFeatureGates(kubevirtFeatureGates),
+		nodesconfig.WithKubeVirtConfig(kubevirtConfig),
+		nodesconfig.WithKubeVirtAutoEmulation(kubevirtAutoEmulation),
+		nodesconfig.WithMetalLB(metallbEnabled),
+		nodesconfig.WithMetalLBAddressPool(metallbAddressPool),
+		nodesconfig.WithMetalLBInterfaces(metallbInterfaces),
+		nodesconfig.WithIngress(deployIngress),
+		nodesconfig.WithIngressDomain(ingressDomain),
+		nodesconfig.WithIngressAddress(ingressAddress),
+		nodesconfig.WithCertManager(deployCertManager),
+		nodesconfig.WithKwok(cmd.Flags().Changed("kwok-nodes")),
+		nodesconfig.WithKwokNodes(kwokNodes),
+		nodesconfig.WithKwokNodeTemplate(kwokNodeTemplate),
+		nodesconfig.WithDRA(draEnabled),
+		nodesconfig.WithDRADevices(draDevices),
+		nodesconfig.WithSriov(deploySriov),
+		nodesconfig.WithCsiHostpath(csiHostpathEnabled),
+		nodesconfig.WithCsiHostpathDefault(csiHostpathDefault),
+		nodesconfig.WithVelero(deployVelero),
+		nodesconfig.WithVeleroInClusterMinIO(veleroMinIO == velero.MinIOInCluster),
+		nodesconfig.WithVeleroKubeVirtPlugin(veleroKubeVirtPlugin),
 	}
 	n := nodesconfig.NewNodeK8sConfig(k8sConfs)
 
@@ -976,10 +1535,43 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 		return err
 	}
 
-	if err = provisionK8sOptions(sshClient, k8sClient, n, prefix); err != nil {
+	k8sVersion, err := k8s.ServerVersion(config)
+	if err != nil {
 		return err
 	}
 
+	cniCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := selectedCNI.WaitReady(cniCtx, k8sClient); err != nil {
+		return err
+	}
+
+	nodeClients := []libssh.Client{sshClient}
+	for x := 2; x <= int(nodes); x++ {
+		nodeClient, err := libssh.NewSSHClient(sshPort, x, true)
+		if err != nil {
+			return err
+		}
+		nodeClients = append(nodeClients, nodeClient)
+	}
+
+	if err = provisionK8sOptions(nodeClients, k8sClient, n, k8sVersion); err != nil {
+		return err
+	}
+
+	// the console of the in-cluster MinIO is a NodePort, it is published on the port of the minio container
+	if deployVelero && veleroMinIO == velero.MinIOInCluster {
+		target := fmt.Sprintf("192.168.66.101:%d", velero.ConsoleNodePort)
+		out := &bytes.Buffer{}
+		success, err := docker.Exec(cli, dnsmasq.ID, []string{"/bin/bash", "-c", fmt.Sprintf(loadBalancerRule, utils.PortMinIOConsole, target)}, out)
+		if err != nil {
+			return err
+		}
+		if !success {
+			return fmt.Errorf("forwarding port %d to %s failed: %s", utils.PortMinIOConsole, target, out.String())
+		}
+	}
+
 	// If background flag was specified, we don't want to clean up if we reach that state
 	if !background {
 		wg.Wait()
@@ -989,65 +1581,136 @@ func run(cmd *cobra.Command, args []string) (retErr error) {
 	return nil
 }
 
-func provisionK8sOptions(sshClient libssh.Client, k8sClient k8s.K8sDynamicClient, n *nodesconfig.NodeK8sConfig, k8sVersion string) error {
-	opts := []opts.Opt{}
+// provisionK8sOptions deploys the enabled add-ons, nodeClients are the ssh clients of all nodes, starting with node01.
+// Add-ons which do not support the Kubernetes version of the cluster are skipped with a warning
+func provisionK8sOptions(nodeClients []libssh.Client, k8sClient k8s.K8sDynamicClient, n *nodesconfig.NodeK8sConfig, k8sVersion *semver.Version) error {
+	sshClient := nodeClients[0]
+	k8sOpts := []opts.ScheduledOpt{}
+
+	// the snapshot CRDs and controller are shared by all storage providers with snapshot support and Velero
+	if n.Ceph || n.CsiHostpath || n.Velero {
+		snapshotControllerOpt := snapshotcontroller.NewSnapshotControllerOpt(k8sClient)
+		k8sOpts = append(k8sOpts, snapshotControllerOpt)
+	}
 
 	if n.Ceph {
-		cephOpt := rookceph.NewCephOpt(k8sClient, sshClient)
-		opts = append(opts, cephOpt)
+		cephOpt := rookceph.NewCephOpt(k8sClient, sshClient, n.CephConfig)
+		k8sOpts = append(k8sOpts, cephOpt)
 	}
 
 	if n.NfsCsi {
-		nfsCsiOpt := nfscsi.NewNfsCsiOpt(k8sClient)
-		opts = append(opts, nfsCsiOpt)
+		nfsCsiOpt := nfscsi.NewNfsCsiOpt(k8sClient, sshClient, n.NfsSecondExport)
+		k8sOpts = append(k8sOpts, nfsCsiOpt)
+	}
+
+	if n.CsiHostpath {
+		csiHostpathOpt := csihostpath.NewCsiHostpathOpt(k8sClient, n.CsiHostpathDefault)
+		k8sOpts = append(k8sOpts, csiHostpathOpt)
 	}
 
 	if n.Multus {
-		multusOpt := multus.NewMultusOpt(k8sClient, sshClient)
-		opts = append(opts, multusOpt)
+		multusOpt := multus.NewMultusOpt(k8sClient, nodeClients)
+		k8sOpts = append(k8sOpts, multusOpt)
+	}
+
+	if n.Whereabouts {
+		whereaboutsOpt := whereabouts.NewWhereaboutsOpt(k8sClient, n.Multus, n.WhereaboutsNetworks)
+		k8sOpts = append(k8sOpts, whereaboutsOpt)
 	}
 
 	if n.CNAO {
-		cnaoOpt := cnao.NewCnaoOpt(k8sClient, sshClient, n.Multus, n.DNC, n.CNAOSkipCR)
-		opts = append(opts, cnaoOpt)
+		cnaoOpt := cnao.NewCnaoOpt(k8sClient, n.Multus, n.DNC, n.CNAOSkipCR)
+		k8sOpts = append(k8sOpts, cnaoOpt)
 	}
 
 	if n.Istio {
 		istioOpt := istio.NewIstioOpt(sshClient, k8sClient, n.CNAO)
-		opts = append(opts, istioOpt)
+		k8sOpts = append(k8sOpts, istioOpt)
 	}
 
 	if n.Prometheus {
-		prometheusOpt := prometheus.NewPrometheusOpt(k8sClient, n.Grafana, n.Alertmanager)
-		opts = append(opts, prometheusOpt)
+		var extra *prometheus.Extra
+		if n.PrometheusExtra != "" {
+			var err error
+			if extra, err = prometheus.LoadExtra(n.PrometheusExtra); err != nil {
+				return err
+			}
+		}
+		prometheusOpt := prometheus.NewPrometheusOpt(k8sClient, n.Grafana, n.Alertmanager, n.Logging, extra)
+		k8sOpts = append(k8sOpts, prometheusOpt)
+	}
+
+	if n.Logging {
+		loggingOpt := logging.NewLoggingOpt(k8sClient)
+		k8sOpts = append(k8sOpts, loggingOpt)
 	}
 
 	if n.CDI {
 		cdi := cdi.NewCdiOpt(k8sClient, sshClient, n.CDIVersion)
-		opts = append(opts, cdi)
+		k8sOpts = append(k8sOpts, cdi)
 	}
 
 	if n.AAQ {
-		if k8sVersion == "k8s-1.30" {
-			aaq := aaq.NewAaqOpt(k8sClient, sshClient, n.CDIVersion)
-			opts = append(opts, aaq)
-		} else {
-			logrus.Info("AAQ was requested but k8s version is not k8s-1.30, skipping")
-		}
+		aaq := aaq.NewAaqOpt(k8sClient, n.AAQVersion)
+		k8sOpts = append(k8sOpts, aaq)
+	}
+
+	if n.KubeVirt {
+		kubevirtOpt := kubevirt.NewKubevirtOpt(k8sClient, sshClient, n.KubeVirtVersion, n.KubeVirtManifests, n.KubeVirtFeatureGates, n.KubeVirtConfig, n.KubeVirtAutoEmulation)
+		k8sOpts = append(k8sOpts, kubevirtOpt)
+	}
+
+	if n.MetalLB {
+		metallbOpt := metallb.NewMetallbOpt(k8sClient, n.MetalLBAddressPool, n.MetalLBInterfaces)
+		k8sOpts = append(k8sOpts, metallbOpt)
+	}
+
+	if n.CertManager {
+		certManagerOpt := certmanager.NewCertManagerOpt(k8sClient)
+		k8sOpts = append(k8sOpts, certManagerOpt)
+	}
+
+	if n.Ingress {
+		ingressOpt := ingress.NewIngressOpt(k8sClient, n.IngressDomain, n.IngressAddress)
+		k8sOpts = append(k8sOpts, ingressOpt)
+	}
+
+	if n.Velero {
+		veleroOpt := velero.NewVeleroOpt(k8sClient, sshClient, n.VeleroInClusterMinIO, n.VeleroKubeVirtPlugin)
+		k8sOpts = append(k8sOpts, veleroOpt)
+	}
+
+	if n.DRA {
+		draOpt := dra.NewDraOpt(k8sClient, nodeClients, n.DRADevices)
+		k8sOpts = append(k8sOpts, draOpt)
+	}
+
+	if n.Sriov {
+		sriovOpt := sriov.NewSriovOpt(k8sClient)
+		k8sOpts = append(k8sOpts, sriovOpt)
+	}
+
+	if n.Kwok {
+		kwokOpt := kwok.NewKwokOpt(k8sClient, sshClient, n.KwokNodes, []byte(n.KwokNodeTemplate))
+		k8sOpts = append(k8sOpts, kwokOpt)
 	}
 
 	if n.NetworkResourcesInjector {
-		networkResourcesInjectorOpt := network_resources_injector.NewNetworkResourcesInjectorOpt(sshClient, k8sClient)
-		opts = append(opts, networkResourcesInjectorOpt)
+		networkResourcesInjectorOpt := network_resources_injector.NewNetworkResourcesInjectorOpt(k8sClient)
+		k8sOpts = append(k8sOpts, networkResourcesInjectorOpt)
 	}
 
-	for _, opt := range opts {
-		if err := opt.Exec(); err != nil {
+	for _, dir := range n.Addons {
+		addon, err := loadLocalAddon(dir)
+		if err != nil {
 			return err
 		}
+		k8sOpts = append(k8sOpts, localaddon.NewLocalAddonOpt(k8sClient, nodeClients, addon))
 	}
 
-	return nil
+	// independent opts run concurrently, each one waits for the opts it depends on
+	_, err := opts.Schedule(k8sVersion, k8sOpts...)
+	return err
 }
 
 func provisionNode(sshClient libssh.Client, n *nodesconfig.NodeLinuxConfig) error {
@@ -1060,7 +1723,7 @@ func provisionNode(sshClient libssh.Client, n *nodesconfig.NodeLinuxConfig) erro
 				return fmt.Errorf("starting fips mode failed: %s", err)
 			}
 		}
-		err := waitForVMToBeUp(cli, n.K8sVersion, nodeName)
+		err := waitForVMToBeUp(cli, n.Prefix, nodeName)
 		if err != nil {
 			return err
 		}
@@ -1099,12 +1762,23 @@ func provisionNode(sshClient libssh.Client, n *nodesconfig.NodeLinuxConfig) erro
 	}
 
 	if n.PSA {
-		psaOpt := psa.NewPsaOpt(sshClient)
+		psaConfig := n.PSAConfig
+		if len(psaConfig) == 0 {
+			var err error
+			if psaConfig, err = psa.DefaultConfig().Render(); err != nil {
+				return err
+			}
+		}
+		psaOpt := psa.NewPsaOpt(sshClient, psaConfig)
 		opts = append(opts, psaOpt)
 	}
 
 	if n.NodeIdx == 1 {
-		n := node01.NewNode01Provisioner(sshClient, n.SingleStack, n.Flannel, n.NoEtcdFsync, n.SecondaryNicBridges)
+		c, err := cni.Get(n.CNI)
+		if err != nil {
+			return err
+		}
+		n := node01.NewNode01Provisioner(sshClient, n.SingleStack, c, n.NoEtcdFsync, n.SecondaryNicBridges)
 		opts = append(opts, n)
 
 	} else {
@@ -1121,6 +1795,11 @@ func provisionNode(sshClient libssh.Client, n *nodesconfig.NodeLinuxConfig) erro
 		opts = append(opts, n)
 	}
 
+	if n.SriovVFs > 0 {
+		vfsOpt := sriov.NewVFsOpt(sshClient, n.SriovVFs)
+		opts = append(opts, vfsOpt)
+	}
+
 	if n.KsmEnabled {
 		ksmOpt := ksm.NewKsmOpt(sshClient, n.KsmScanInterval, n.KsmPageCount)
 		opts = append(opts, ksmOpt)
@@ -1191,6 +1870,20 @@ func nodeContainer(prefix string, node string) string {
 	return prefix + "-" + node
 }
 
+// nfsVolumeOptions returns the options of a volume exported by the nfs server, with a tmpfsSize the volume is kept in
+// memory
+func nfsVolumeOptions(name string, tmpfsSize int64) volume.CreateOptions {
+	options := volume.CreateOptions{Name: name}
+	if tmpfsSize > 0 {
+		options.DriverOpts = map[string]string{
+			"type":   "tmpfs",
+			"device": "tmpfs",
+			"o":      fmt.Sprintf("size=%d", tmpfsSize),
+		}
+	}
+	return options
+}
+
 // getDeviceIOMMUGroup gets devices iommu_group
 // e.g. /sys/bus/pci/devices/0000\:65\:00.0/iommu_group -> ../../../../../kernel/iommu_groups/45
 func getPCIDeviceIOMMUGroup(pciAddress string) (string, error) {
@@ -1229,3 +1922,79 @@ func getNetDeviceByArch() string {
 		return QEMU_DEVICE_X86_64
 	}
 }
+
+// kubevirtConfiguration returns the yaml of --kubevirt-config, or the content of --kubevirt-config-file
+func kubevirtConfiguration(flags *pflag.FlagSet) (string, error) {
+	config, err := flags.GetString("kubevirt-config")
+	if err != nil {
+		return "", err
+	}
+	configFile, err := flags.GetString("kubevirt-config-file")
+	if err != nil {
+		return "", err
+	}
+	if configFile != "" {
+		if config != "" {
+			return "", fmt.Errorf("--kubevirt-config can not be used with --kubevirt-config-file")
+		}
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return "", err
+		}
+		config = string(data)
+	}
+
+	if _, err := kubevirt.ParseConfiguration(config); err != nil {
+		return "", fmt.Errorf("invalid kubevirt configuration: %w", err)
+	}
+	return config, nil
+}
+
+// admissionConfig returns the AdmissionConfiguration of the --psa-* flags, or the one of --psa-config, it is nil
+// without --enable-psa
+func admissionConfig(flags *pflag.FlagSet, psaEnabled bool) ([]byte, error) {
+	levelFlags := []string{"psa-enforce", "psa-audit", "psa-warn", "psa-exempt-namespaces"}
+	for _, flag := range append(levelFlags, "psa-config") {
+		if flags.Changed(flag) && !psaEnabled {
+			return nil, fmt.Errorf("--%s requires --enable-psa", flag)
+		}
+	}
+	if !psaEnabled {
+		return nil, nil
+	}
+
+	configFile, err := flags.GetString("psa-config")
+	if err != nil {
+		return nil, err
+	}
+	if configFile != "" {
+		for _, flag := range levelFlags {
+			if flags.Changed(flag) {
+				return nil, fmt.Errorf("--%s can not be used with --psa-config", flag)
+			}
+		}
+		config, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := psa.ValidateAdmissionConfig(config); err != nil {
+			return nil, fmt.Errorf("invalid --psa-config %s: %w", configFile, err)
+		}
+		return config, nil
+	}
+
+	config := psa.Config{}
+	if config.Enforce, err = flags.GetString("psa-enforce"); err != nil {
+		return nil, err
+	}
+	if config.Audit, err = flags.GetString("psa-audit"); err != nil {
+		return nil, err
+	}
+	if config.Warn, err = flags.GetString("psa-warn"); err != nil {
+		return nil, err
+	}
+	if config.ExemptNamespaces, err = flags.GetStringSlice("psa-exempt-namespaces"); err != nil {
+		return nil, err
+	}
+	return config.Render()
+}
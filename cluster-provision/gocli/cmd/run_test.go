@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/cmd/nodesconfig"
-	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/aaq"
 	bindvfio "kubevirt.io/kubevirtci/cluster-provision/gocli/opts/bind-vfio"
 	etcdinmemory "kubevirt.io/kubevirtci/cluster-provision/gocli/opts/etcd"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/istio"
@@ -13,7 +16,10 @@ import (
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/node01"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/psa"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/rookceph"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/sriov"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/velero"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
@@ -32,6 +38,8 @@ var _ = Describe("Node Provisioning", func() {
 			k8s.NewReactorConfig("create", "istiooperators", istio.IstioReactor),
 			k8s.NewReactorConfig("create", "cephblockpools", rookceph.CephReactor),
 			k8s.NewReactorConfig("create", "persistentvolumeclaims", nfscsi.NfsCsiReactor),
+			k8s.NewReactorConfig("create", "aaqs", k8s.ConditionReactor("Available")),
+			k8s.NewReactorConfig("create", "jobs", k8s.ConditionReactor("Complete")),
 		}
 
 		k8sClient = k8s.NewTestClient(reactors...)
@@ -49,17 +57,21 @@ var _ = Describe("Node Provisioning", func() {
 				nodesconfig.WithEtcdInMemory(true),
 				nodesconfig.WithEtcdSize("1G"),
 				nodesconfig.WithPSA(true),
+				nodesconfig.WithSriovVFs(7),
 			}
 
-			n := nodesconfig.NewNodeLinuxConfig(1, "k8s-1.30", linuxConfigFuncs)
+			n := nodesconfig.NewNodeLinuxConfig(1, "k8s-1.30", semver.MustParse("1.30.0"), linuxConfigFuncs)
 
 			etcdinmemory.AddExpectCalls(sshClient, "1G")
 			bindvfio.AddExpectCalls(sshClient, "8086:2668")
 			bindvfio.AddExpectCalls(sshClient, "8086:293e")
-			psa.AddExpectCalls(sshClient)
+			psaConfig, err := psa.DefaultConfig().Render()
+			Expect(err).NotTo(HaveOccurred())
+			psa.AddExpectCalls(sshClient, psaConfig)
 			node01.AddExpectCalls(sshClient)
+			sriov.AddExpectCalls(sshClient, 7)
 
-			err := provisionNode(sshClient, n)
+			err = provisionNode(sshClient, n)
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
@@ -71,18 +83,91 @@ var _ = Describe("Node Provisioning", func() {
 				nodesconfig.WithPrometheus(true),
 				nodesconfig.WithAlertmanager(true),
 				nodesconfig.WithGrafana(true),
+				nodesconfig.WithLogging(true),
 				nodesconfig.WithIstio(true),
 				nodesconfig.WithNfsCsi(true),
+				nodesconfig.WithCsiHostpath(true),
+				nodesconfig.WithVelero(true),
 				nodesconfig.WithAAQ(true),
 			}
 			n := nodesconfig.NewNodeK8sConfig(k8sConfs)
 
 			rookceph.AddExpectCalls(sshClient)
 			istio.AddExpectCalls(sshClient)
-			aaq.AddExpectCalls(sshClient)
+			nfscsi.AddExpectCalls(sshClient)
+			velero.AddExpectCalls(sshClient, false, false)
+			Expect(istio.CreateCNIDaemonSet(k8sClient)).To(Succeed())
 
-			err := provisionK8sOptions(sshClient, k8sClient, n, "k8s-1.30")
+			err := provisionK8sOptions([]libssh.Client{sshClient}, k8sClient, n, semver.MustParse("1.30.0"))
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
 })
+
+var _ = Describe("Pod Security Admission flags", func() {
+	admissionConfigOf := func(args ...string) ([]byte, error) {
+		flags := NewRunCommand().Flags()
+		Expect(flags.Parse(args)).To(Succeed())
+		psaEnabled, err := flags.GetBool("enable-psa")
+		Expect(err).NotTo(HaveOccurred())
+		return admissionConfig(flags, psaEnabled)
+	}
+
+	It("should render the levels and exemptions", func() {
+		config, err := admissionConfigOf("--enable-psa", "--psa-enforce=baseline", "--psa-exempt-namespaces=kube-system,kubevirt")
+		Expect(err).NotTo(HaveOccurred())
+		expected, err := psa.Config{Enforce: "baseline", Audit: "restricted", Warn: "restricted", ExemptNamespaces: []string{"kube-system", "kubevirt"}}.Render()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(expected))
+	})
+
+	It("should use the admission configuration file", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "psa.yaml")
+		content := []byte("apiVersion: apiserver.config.k8s.io/v1\nkind: AdmissionConfiguration\nplugins:\n- name: PodSecurity\n  configuration:\n    defaults:\n      enforce: baseline\n")
+		Expect(os.WriteFile(file, content, 0644)).To(Succeed())
+
+		config, err := admissionConfigOf("--enable-psa", "--psa-config="+file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(content))
+	})
+
+	DescribeTable("should refuse invalid combinations", func(expected string, args ...string) {
+		_, err := admissionConfigOf(args...)
+		Expect(err).To(MatchError(ContainSubstring(expected)))
+	},
+		Entry("levels without --enable-psa", "--psa-enforce requires --enable-psa", "--psa-enforce=baseline"),
+		Entry("levels with a file", "--psa-warn can not be used with --psa-config", "--enable-psa", "--psa-config=psa.yaml", "--psa-warn=baseline"),
+		Entry("unknown level", "invalid enforce level", "--enable-psa", "--psa-enforce=strict"),
+	)
+})
+
+var _ = Describe("KubeVirt configuration flags", func() {
+	configurationOf := func(args ...string) (string, error) {
+		flags := NewRunCommand().Flags()
+		Expect(flags.Parse(args)).To(Succeed())
+		return kubevirtConfiguration(flags)
+	}
+
+	It("should take the yaml of --kubevirt-config", func() {
+		config, err := configurationOf(`--kubevirt-config={"vmRolloutStrategy": "LiveUpdate"}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal(`{"vmRolloutStrategy": "LiveUpdate"}`))
+	})
+
+	It("should read the yaml of --kubevirt-config-file", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "kubevirt.yaml")
+		Expect(os.WriteFile(file, []byte("vmRolloutStrategy: LiveUpdate\n"), 0644)).To(Succeed())
+
+		config, err := configurationOf("--kubevirt-config-file=" + file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(Equal("vmRolloutStrategy: LiveUpdate\n"))
+	})
+
+	DescribeTable("should refuse", func(expected string, args ...string) {
+		_, err := configurationOf(args...)
+		Expect(err).To(MatchError(ContainSubstring(expected)))
+	},
+		Entry("both flags", "--kubevirt-config can not be used with --kubevirt-config-file", "--kubevirt-config=vmRolloutStrategy: LiveUpdate", "--kubevirt-config-file=kubevirt.yaml"),
+		Entry("a missing file", "no such file or directory", "--kubevirt-config-file=/nonexistent/kubevirt.yaml"),
+	)
+})
@@ -5,4 +5,6 @@ const (
 	NFSServerImage = "quay.io/kubevirtci/gists-nfs-server:2.6.4"
 	// DockerRegistryImage contains the reference to docker registry docker image
 	DockerRegistryImage = "quay.io/libpod/registry:2.8.2"
+	// MinIOImage contains the reference to the MinIO docker image Velero stores backups in
+	MinIOImage = "quay.io/minio/minio:RELEASE.2024-12-18T13-15-44Z"
 )
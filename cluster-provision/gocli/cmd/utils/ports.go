@@ -36,6 +36,10 @@ const (
 	PortUploadProxyLowerBand = 30085
 	//PortDNS contains DNS port
 	PortDNS = 31111
+	// PortLoki contains Loki API port
+	PortLoki = 30010
+	// PortMinIOConsole contains MinIO console port
+	PortMinIOConsole = 9001
 	// PortNameSSH contains control-plane node SSH port name
 	PortNameSSH = "ssh"
 	// PortNameSSHWorker contains worker node SSH port name
@@ -65,6 +69,14 @@ const (
 	PortNameUploadProxyLowerBand = "uploadproxy-lowerband"
 	// PortNameDNS contains UDP port
 	PortNameDNS = "dns"
+	// PortNameLoki contains Loki API port name
+	PortNameLoki = "loki"
+	// PortNameMinIO contains MinIO console port name
+	PortNameMinIO = "minio"
+	// PortLoadBalancer contains the first of the ports reserved for publishing LoadBalancer IPs of MetalLB
+	PortLoadBalancer = 32600
+	// LoadBalancerPortCount contains the number of ports reserved for publishing LoadBalancer IPs
+	LoadBalancerPortCount = 10
 )
 
 // GetPublicPort returns public port by private port
@@ -16,9 +16,12 @@ type DNSMasqOptions struct {
 	ClusterImage       string
 	NodeCount          uint
 	SecondaryNicsCount uint
+	SriovNicsCount     uint
 	RandomPorts        bool
 	PortMap            nat.PortMap
 	Prefix             string
+	// LoadBalancerPorts exposes the ports gocli ports loadbalancer publishes LoadBalancer IPs on
+	LoadBalancerPorts bool
 }
 
 func DNSMasq(cli *client.Client, ctx context.Context, options *DNSMasqOptions) (*container.CreateResponse, error) {
@@ -37,28 +40,38 @@ func DNSMasq(cli *client.Client, ctx context.Context, options *DNSMasqOptions) (
 
 	}
 
+	exposedPorts := nat.PortSet{
+		utils.TCPPortOrDie(utils.PortSSH):                  {},
+		utils.TCPPortOrDie(utils.PortRegistry):             {},
+		utils.TCPPortOrDie(utils.PortOCP):                  {},
+		utils.TCPPortOrDie(utils.PortAPI):                  {},
+		utils.TCPPortOrDie(utils.PortVNC):                  {},
+		utils.TCPPortOrDie(utils.PortHTTP):                 {},
+		utils.TCPPortOrDie(utils.PortHTTPS):                {},
+		utils.TCPPortOrDie(utils.PortPrometheus):           {},
+		utils.TCPPortOrDie(utils.PortGrafana):              {},
+		utils.TCPPortOrDie(utils.PortUploadProxy):          {},
+		utils.TCPPortOrDie(utils.PortUploadProxyLowerBand): {},
+		utils.TCPPortOrDie(utils.PortLoki):                 {},
+		utils.TCPPortOrDie(utils.PortMinIOConsole):         {},
+		utils.UDPPortOrDie(utils.PortDNS):                  {},
+	}
+	if options.LoadBalancerPorts {
+		for port := utils.PortLoadBalancer; port < utils.PortLoadBalancer+utils.LoadBalancerPortCount; port++ {
+			exposedPorts[utils.TCPPortOrDie(port)] = struct{}{}
+		}
+	}
+
 	// Start dnsmasq
 	dnsmasq, err := cli.ContainerCreate(ctx, &container.Config{
 		Image: options.ClusterImage,
 		Env: []string{
 			fmt.Sprintf("NUM_NODES=%d", options.NodeCount),
 			fmt.Sprintf("NUM_SECONDARY_NICS=%d", options.SecondaryNicsCount),
+			fmt.Sprintf("NUM_SRIOV_NICS=%d", options.SriovNicsCount),
 		},
-		Cmd: []string{"/bin/bash", "-c", "/dnsmasq.sh"},
-		ExposedPorts: nat.PortSet{
-			utils.TCPPortOrDie(utils.PortSSH):                  {},
-			utils.TCPPortOrDie(utils.PortRegistry):             {},
-			utils.TCPPortOrDie(utils.PortOCP):                  {},
-			utils.TCPPortOrDie(utils.PortAPI):                  {},
-			utils.TCPPortOrDie(utils.PortVNC):                  {},
-			utils.TCPPortOrDie(utils.PortHTTP):                 {},
-			utils.TCPPortOrDie(utils.PortHTTPS):                {},
-			utils.TCPPortOrDie(utils.PortPrometheus):           {},
-			utils.TCPPortOrDie(utils.PortGrafana):              {},
-			utils.TCPPortOrDie(utils.PortUploadProxy):          {},
-			utils.TCPPortOrDie(utils.PortUploadProxyLowerBand): {},
-			utils.UDPPortOrDie(utils.PortDNS):                  {},
-		},
+		Cmd:          []string{"/bin/bash", "-c", "/dnsmasq.sh"},
+		ExposedPorts: exposedPorts,
 	}, &container.HostConfig{
 		Privileged:      true,
 		PublishAllPorts: options.RandomPorts,
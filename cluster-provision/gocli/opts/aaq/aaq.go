@@ -1,14 +1,15 @@
 package aaq
 
 import (
-	"bytes"
+	"context"
 	_ "embed"
-	"fmt"
-	"regexp"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
-	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
 //go:embed manifests/operator.yaml
@@ -17,52 +18,67 @@ var operator []byte
 //go:embed manifests/cr.yaml
 var cr []byte
 
+var aaqGVK = schema.GroupVersionKind{Group: "aaq.kubevirt.io", Version: "v1alpha1", Kind: "AAQ"}
+
 type aaqOpt struct {
 	client        k8s.K8sDynamicClient
-	sshClient     libssh.Client
 	customVersion string
 }
 
-func NewAaqOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, customVersion string) *aaqOpt {
+func NewAaqOpt(c k8s.K8sDynamicClient, customVersion string) *aaqOpt {
 	return &aaqOpt{
 		client:        c,
-		sshClient:     sshClient,
 		customVersion: customVersion,
 	}
 }
 
+func (o *aaqOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "aaq", KubernetesVersion: ">=1.30"}
+}
+
 func (o *aaqOpt) Exec() error {
-	if o.customVersion != "" {
-		pattern := `v[0-9]+\.[0-9]+\.[0-9]+(.*)?$`
-		regex, err := regexp.Compile(pattern)
-		if err != nil {
-			return err
-		}
-		operatorNewVersion := regex.ReplaceAllString(string(operator), o.customVersion)
-		operator = []byte(operatorNewVersion)
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
 	}
-
-	for _, manifest := range [][]byte{operator, cr} {
-		yamlDocs := bytes.Split(manifest, []byte("---\n"))
-		for _, yamlDoc := range yamlDocs {
-			if len(yamlDoc) == 0 {
-				continue
-			}
-
-			obj, err := k8s.SerializeIntoObject(yamlDoc)
-			if err != nil {
-				logrus.Info(err.Error())
-				continue
-			}
-			if err := o.client.Apply(obj); err != nil {
-				return fmt.Errorf("error applying manifest %s", err)
-			}
-		}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
 	}
 
-	if err := o.sshClient.Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf wait --for=condition=Ready pod --timeout=180s --all --namespace aaq"); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+	if err := k8s.WaitForDeploymentRollout(ctx, o.client, "aaq-operator", "aaq"); err != nil {
+		return err
+	}
+	// the operator reports the AAQ CR as available once it deployed the aaq server and controller
+	if err := k8s.WaitForCondition(ctx, o.client, aaqGVK, "aaq", "", "Available"); err != nil {
 		return err
 	}
 	logrus.Info("AAQ Operator is ready!")
 	return nil
 }
+
+func (o *aaqOpt) Uninstall() error {
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *aaqOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, aaqGVK, "aaq", "", k8s.ConditionTrue("Available"))
+}
+
+func (o *aaqOpt) bundle() (*common.Bundle, error) {
+	bundle, err := common.ParseBundle(operator, cr)
+	if err != nil {
+		return nil, err
+	}
+	if o.customVersion != "" {
+		if err := bundle.Mutate(common.WithImageTag(o.customVersion, "aaq-")); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
@@ -1,9 +0,0 @@
-package aaq
-
-import (
-	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
-)
-
-func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient) {
-	sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf wait --for=condition=Ready pod --timeout=180s --all --namespace aaq")
-}
@@ -22,7 +22,7 @@ func NewBindVfioOpt(sshClient libssh.Client, id string) *bindVfioOpt {
 func (o *bindVfioOpt) Exec() error {
 	addr, err := o.sshClient.CommandWithNoStdOut("lspci -D -d " + o.pciID)
 	if err != nil {
-		return err
+		return fmt.Errorf("error looking up PCI device %s: %w", o.pciID, err)
 	}
 
 	pciDevId := strings.Split(addr, " ")[0]
@@ -33,12 +33,12 @@ func (o *bindVfioOpt) Exec() error {
 
 	driver, err := o.sshClient.CommandWithNoStdOut("readlink " + driverPath + " | awk -F'/' '{print $NF}'")
 	if err != nil {
-		return err
+		return fmt.Errorf("error reading the driver of PCI device %s: %w", pciDevId, err)
 	}
 	driver = strings.TrimSuffix(driver, "\n")
 
 	if err := o.sshClient.Command("modprobe -i vfio-pci"); err != nil {
-		return fmt.Errorf("error loading vfio-pci module: %v", err)
+		return fmt.Errorf("error loading vfio-pci module: %w", err)
 	}
 
 	cmds := []string{
@@ -49,7 +49,7 @@ func (o *bindVfioOpt) Exec() error {
 
 	for _, cmd := range cmds {
 		if err := o.sshClient.Command(cmd); err != nil {
-			return err
+			return fmt.Errorf("error binding PCI device %s to vfio-pci: %w", pciDevId, err)
 		}
 	}
 
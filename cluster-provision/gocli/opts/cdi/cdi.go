@@ -1,12 +1,11 @@
 package cdi
 
 import (
-	"bytes"
 	_ "embed"
-	"fmt"
-	"regexp"
 
-	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
@@ -17,6 +16,8 @@ var operator []byte
 //go:embed manifests/cdi-cr.yaml
 var cr []byte
 
+var cdiGVK = schema.GroupVersionKind{Group: "cdi.kubevirt.io", Version: "v1beta1", Kind: "CDI"}
+
 type cdiOpt struct {
 	client        k8s.K8sDynamicClient
 	sshClient     libssh.Client
@@ -31,34 +32,39 @@ func NewCdiOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, cv string) *cdiO
 	}
 }
 
+func (o *cdiOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "cdi"}
+}
+
 func (o *cdiOpt) Exec() error {
-	if o.customVersion != "" {
-		pattern := `v[0-9]+\.[0-9]+\.[0-9]+(.*)?$`
-		regex, err := regexp.Compile(pattern)
-		if err != nil {
-			return err
-		}
-		operatorNewVersion := regex.ReplaceAllString(string(operator), o.customVersion)
-		operator = []byte(operatorNewVersion)
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
 	}
+	return bundle.Apply(o.client)
+}
 
-	for _, manifest := range [][]byte{operator, cr} {
-		yamlDocs := bytes.Split(manifest, []byte("---\n"))
-		for _, yamlDoc := range yamlDocs {
-			if len(yamlDoc) == 0 {
-				continue
-			}
+func (o *cdiOpt) Uninstall() error {
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *cdiOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, cdiGVK, "cdi", "", k8s.ConditionTrue("Available"))
+}
 
-			obj, err := k8s.SerializeIntoObject(yamlDoc)
-			if err != nil {
-				logrus.Info(err.Error())
-				continue
-			}
-			if err := o.client.Apply(obj); err != nil {
-				return fmt.Errorf("error applying manifest %s", err)
-			}
+func (o *cdiOpt) bundle() (*common.Bundle, error) {
+	bundle, err := common.ParseBundle(operator, cr)
+	if err != nil {
+		return nil, err
+	}
+	if o.customVersion != "" {
+		if err := bundle.Mutate(common.WithImageTag(o.customVersion, "cdi-")); err != nil {
+			return nil, err
 		}
 	}
-
-	return nil
+	return bundle, nil
 }
@@ -0,0 +1,123 @@
+package certmanager
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/cert-manager.yaml
+var certManager []byte
+
+//go:embed manifests/issuer.yaml
+var issuer []byte
+
+// CAIssuer is the ClusterIssuer which issues certificates signed by the CA of the cluster
+const CAIssuer = "kubevirtci-ca"
+
+const (
+	namespace = "cert-manager"
+	// caSecret holds the CA of CAIssuer, it is named after the Certificate of the CA
+	caSecret = "kubevirtci-ca"
+)
+
+var (
+	clusterIssuerGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}
+	certificateGVK   = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+)
+
+type certManagerOpt struct {
+	client k8s.K8sDynamicClient
+}
+
+// NewCertManagerOpt creates an opt which deploys cert-manager with a generated CA behind the CAIssuer ClusterIssuer
+func NewCertManagerOpt(c k8s.K8sDynamicClient) *certManagerOpt {
+	return &certManagerOpt{
+		client: c,
+	}
+}
+
+func (o *certManagerOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "cert-manager"}
+}
+
+func (o *certManagerOpt) Exec() error {
+	bundle, err := common.ParseBundle(certManager)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	// the webhook validates the issuers, and serves once the cainjector injected its CA into the webhook configurations
+	for _, deployment := range []string{"cert-manager", "cert-manager-cainjector", "cert-manager-webhook"} {
+		if err := k8s.WaitForDeploymentRollout(ctx, o.client, deployment, namespace); err != nil {
+			return err
+		}
+	}
+
+	issuers, err := common.ParseBundle(issuer)
+	if err != nil {
+		return err
+	}
+	if err := issuers.Apply(o.client); err != nil {
+		return err
+	}
+	if err := k8s.WaitForCondition(ctx, o.client, certificateGVK, caSecret, namespace, "Ready"); err != nil {
+		return err
+	}
+	if err := k8s.WaitForCondition(ctx, o.client, clusterIssuerGVK, CAIssuer, "", "Ready"); err != nil {
+		return err
+	}
+	logrus.Infof("cert-manager is ready, certificates are issued by the %s ClusterIssuer", CAIssuer)
+	return nil
+}
+
+func (o *certManagerOpt) Uninstall() error {
+	issuers, err := common.ParseBundle(issuer)
+	if err != nil {
+		return err
+	}
+	if err := issuers.Delete(o.client); err != nil {
+		return err
+	}
+
+	bundle, err := common.ParseBundle(certManager)
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *certManagerOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, clusterIssuerGVK, CAIssuer, "", k8s.ConditionTrue("Ready"))
+}
+
+// CA returns the PEM encoded certificate of the CA behind CAIssuer
+func CA(client k8s.K8sDynamicClient) ([]byte, error) {
+	secret, err := client.Get(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, caSecret, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the CA of the cluster, is cert-manager deployed? %w", err)
+	}
+	// the CA is self-signed, so the secret holds it as tls.crt as well as ca.crt
+	encoded, _, err := unstructured.NestedString(secret.Object, "data", "tls.crt")
+	if err != nil {
+		return nil, err
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("secret %s/%s holds no certificate", namespace, caSecret)
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
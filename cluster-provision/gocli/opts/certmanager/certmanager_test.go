@@ -0,0 +1,67 @@
+package certmanager
+
+import (
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestCertManagerOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CertManagerOpt Suite")
+}
+
+var _ = Describe("CertManagerOpt", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient(
+			k8s.NewReactorConfig("create", "certificates", k8s.ConditionReactor("Ready")),
+			k8s.NewReactorConfig("create", "clusterissuers", k8s.ConditionReactor("Ready")),
+		)
+	})
+
+	It("should deploy cert-manager with a CA issuer", func() {
+		opt := NewCertManagerOpt(client)
+		Expect(opt.Exec()).To(Succeed())
+
+		_, err := client.Get(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "cert-manager-webhook", namespace)
+		Expect(err).NotTo(HaveOccurred())
+
+		ca, err := client.Get(certificateGVK, caSecret, namespace)
+		Expect(err).NotTo(HaveOccurred())
+		isCA, _, err := unstructured.NestedBool(ca.Object, "spec", "isCA")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isCA).To(BeTrue())
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err = opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+
+	It("should return the CA of the cluster", func() {
+		secret := &unstructured.Unstructured{}
+		secret.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+		secret.SetName(caSecret)
+		secret.SetNamespace(namespace)
+		Expect(unstructured.SetNestedField(secret.Object, base64.StdEncoding.EncodeToString([]byte("PEM")), "data", "tls.crt")).To(Succeed())
+		Expect(client.Apply(secret)).To(Succeed())
+
+		Expect(CA(client)).To(Equal([]byte("PEM")))
+	})
+
+	It("should fail to return the CA without cert-manager", func() {
+		_, err := CA(client)
+		Expect(err).To(MatchError(ContainSubstring("is cert-manager deployed?")))
+	})
+})
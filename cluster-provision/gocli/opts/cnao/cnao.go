@@ -1,92 +1,85 @@
 package cnao
 
 import (
-	"bytes"
+	"context"
 	"embed"
-	"fmt"
-	"io/fs"
-	"path/filepath"
-	"regexp"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
-	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
 //go:embed manifests/*
 var f embed.FS
 
+var deploymentGVK = appsv1.SchemeGroupVersion.WithKind("Deployment")
+
 type cnaoOpt struct {
 	client        k8s.K8sDynamicClient
-	sshClient     libssh.Client
 	multusEnabled bool
 	dncEnabled    bool
 	skipCR        bool
 }
 
-func NewCnaoOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, multusEnabled, dncEnabled, skipCR bool) *cnaoOpt {
+func NewCnaoOpt(c k8s.K8sDynamicClient, multusEnabled, dncEnabled, skipCR bool) *cnaoOpt {
 	return &cnaoOpt{
 		client:        c,
-		sshClient:     sshClient,
 		multusEnabled: multusEnabled,
 		skipCR:        skipCR,
 		dncEnabled:    dncEnabled,
 	}
 }
 
-func (o *cnaoOpt) Exec() error {
-	err := fs.WalkDir(f, "manifests", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() && filepath.Ext(path) == ".yaml" {
-			yamlData, err := f.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			yamlDocs := bytes.Split(yamlData, []byte("---\n"))
-			for _, yamlDoc := range yamlDocs {
-				if len(yamlDoc) == 0 {
-					continue
-				}
+func (o *cnaoOpt) Descriptor() opts.Descriptor {
+	deps := []string{}
+	if o.multusEnabled {
+		// the CR leaves multus to the multus opt
+		deps = append(deps, "multus")
+	}
+	return opts.Descriptor{Name: "cnao", Dependencies: deps}
+}
 
-				if path == "manifests/network-addons-config-example.cr.yaml" {
-					if o.skipCR {
-						continue
-					}
+func (o *cnaoOpt) Exec() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
 
-					if o.multusEnabled {
-						re := regexp.MustCompile("(?m)[\r\n]+^.*multus:.*$")
-						res := re.ReplaceAllString(string(yamlDoc), "")
-						yamlDoc = []byte(res)
-					}
+	if o.skipCR {
+		bundle.Without("NetworkAddonsConfig")
+	}
+	mutators := []common.Mutator{}
+	if o.multusEnabled {
+		// multus is deployed on its own
+		mutators = append(mutators, common.WithoutField("NetworkAddonsConfig", "spec", "multus"))
+	}
+	if !o.dncEnabled {
+		mutators = append(mutators, common.WithoutField("NetworkAddonsConfig", "spec", "multusDynamicNetworks"))
+	}
+	if err := bundle.Mutate(mutators...); err != nil {
+		return err
+	}
 
-					if !o.dncEnabled {
-						re := regexp.MustCompile("(?m)[\r\n]+^.*multusDynamicNetworks:.*$")
-						res := re.ReplaceAllString(string(yamlDoc), "")
-						yamlDoc = []byte(res)
-					}
-				}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
 
-				obj, err := k8s.SerializeIntoObject(yamlDoc)
-				if err != nil {
-					logrus.Info(err.Error())
-					continue
-				}
-				if err := o.client.Apply(obj); err != nil {
-					return fmt.Errorf("error applying manifest %s", err)
-				}
-			}
-		}
-		return nil
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	return k8s.WaitForDeploymentRollout(ctx, o.client, "cluster-network-addons-operator", "cluster-network-addons")
+}
 
+func (o *cnaoOpt) Uninstall() error {
+	// the CR is deleted regardless of skipCR, it might have been created after the operator was deployed
+	bundle, err := common.LoadBundle(f, "manifests")
 	if err != nil {
 		return err
 	}
+	return bundle.Delete(o.client)
+}
 
-	if err := o.sshClient.Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf wait deployment -n cluster-network-addons cluster-network-addons-operator --for condition=Available --timeout=200s"); err != nil {
-		return err
-	}
-	return nil
+func (o *cnaoOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, deploymentGVK, "cluster-network-addons-operator", "cluster-network-addons", k8s.DeploymentRolledOut)
 }
@@ -5,10 +5,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"go.uber.org/mock/gomock"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
-	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
 func TestCnaoOpt(t *testing.T) {
@@ -18,9 +16,7 @@ func TestCnaoOpt(t *testing.T) {
 
 var _ = Describe("CnaoOpt", func() {
 	var (
-		mockCtrl      *gomock.Controller
 		client        k8s.K8sDynamicClient
-		sshClient     *kubevirtcimocks.MockSSHClient
 		opt           *cnaoOpt
 		skipCR        bool
 		dncEnabled    bool
@@ -28,13 +24,7 @@ var _ = Describe("CnaoOpt", func() {
 	)
 
 	BeforeEach(func() {
-		mockCtrl = gomock.NewController(GinkgoT())
 		client = k8s.NewTestClient()
-		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
-	})
-
-	AfterEach(func() {
-		mockCtrl.Finish()
 	})
 
 	It("should execute create CNAO with Multus", func() {
@@ -42,9 +32,8 @@ var _ = Describe("CnaoOpt", func() {
 		dncEnabled = true
 		multusEnabled = false
 
-		opt = NewCnaoOpt(client, sshClient, multusEnabled, dncEnabled, skipCR)
+		opt = NewCnaoOpt(client, multusEnabled, dncEnabled, skipCR)
 
-		sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf wait deployment -n cluster-network-addons cluster-network-addons-operator --for condition=Available --timeout=200s")
 		Expect(opt.Exec()).To(Succeed())
 
 		obj, err := client.Get(schema.GroupVersionKind{Group: "networkaddonsoperator.network.kubevirt.io",
@@ -63,8 +52,7 @@ var _ = Describe("CnaoOpt", func() {
 		dncEnabled = false
 		multusEnabled = true
 
-		opt = NewCnaoOpt(client, sshClient, multusEnabled, dncEnabled, skipCR)
-		sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf wait deployment -n cluster-network-addons cluster-network-addons-operator --for condition=Available --timeout=200s")
+		opt = NewCnaoOpt(client, multusEnabled, dncEnabled, skipCR)
 		Expect(opt.Exec()).To(Succeed())
 
 		obj, err := client.Get(schema.GroupVersionKind{Group: "networkaddonsoperator.network.kubevirt.io",
@@ -83,8 +71,7 @@ var _ = Describe("CnaoOpt", func() {
 		dncEnabled = true
 		multusEnabled = false
 
-		opt = NewCnaoOpt(client, sshClient, multusEnabled, dncEnabled, skipCR)
-		sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf wait deployment -n cluster-network-addons cluster-network-addons-operator --for condition=Available --timeout=200s")
+		opt = NewCnaoOpt(client, multusEnabled, dncEnabled, skipCR)
 		Expect(opt.Exec()).To(Succeed())
 
 		obj, err := client.Get(schema.GroupVersionKind{Group: "networkaddonsoperator.network.kubevirt.io",
@@ -0,0 +1,61 @@
+package cni
+
+func init() {
+	// Calico is the CNI of the provider since the first kubevirtci releases
+	Register(&CNI{
+		Name:            "calico",
+		KubeadmConf:     "/etc/kubernetes/kubeadm.conf",
+		IPv6KubeadmConf: "/etc/kubernetes/kubeadm_ipv6.conf",
+		Manifest:        "/provision/cni.yaml",
+		IPv6Manifest:    "/provision/cni_ipv6.yaml",
+		Workloads: []Workload{
+			{Kind: "DaemonSet", Name: "calico-node", Namespace: "kube-system"},
+			{Kind: "Deployment", Name: "calico-kube-controllers", Namespace: "kube-system"},
+		},
+	})
+
+	// flannel does not implement network policies, kube-network-policies does it instead
+	Register(&CNI{
+		Name:            "flannel",
+		KubeadmConf:     "/etc/kubernetes/kubeadm_flannel.conf",
+		IPv6KubeadmConf: "/etc/kubernetes/kubeadm_flannel_ipv6.conf",
+		Manifest:        "/etc/kubernetes/flannel.yaml",
+		IPv6Manifest:    "/etc/kubernetes/flannel_ipv6.yaml",
+		PostInstall: []string{
+			"kubectl --kubeconfig=/etc/kubernetes/admin.conf create -f /etc/kubernetes/knp.yaml",
+		},
+		Workloads: []Workload{
+			{Kind: "DaemonSet", Name: "kube-flannel-ds", Namespace: "kube-flannel"},
+			{Kind: "DaemonSet", Name: "kube-network-policies", Namespace: "kube-system"},
+		},
+	})
+
+	// Cilium allocates the pod IPs from the pod CIDRs kube-controller-manager assigns to the nodes and keeps
+	// kube-proxy, so it shares the pod subnets of calico
+	Register(&CNI{
+		Name:            "cilium",
+		KubeadmConf:     "/etc/kubernetes/kubeadm.conf",
+		IPv6KubeadmConf: "/etc/kubernetes/kubeadm_ipv6.conf",
+		Manifest:        "/etc/kubernetes/cilium.yaml",
+		IPv6Manifest:    "/etc/kubernetes/cilium_ipv6.yaml",
+		Workloads: []Workload{
+			{Kind: "DaemonSet", Name: "cilium", Namespace: "kube-system"},
+			{Kind: "Deployment", Name: "cilium-operator", Namespace: "kube-system"},
+		},
+	})
+
+	// OVN-Kubernetes implements services on its own and runs one zone per node, its IPv6 host subnets are /64
+	Register(&CNI{
+		Name:            "ovn-kubernetes",
+		KubeadmConf:     "/etc/kubernetes/kubeadm_ovn.conf",
+		IPv6KubeadmConf: "/etc/kubernetes/kubeadm_ovn_ipv6.conf",
+		Manifest:        "/etc/kubernetes/ovn-kubernetes.yaml",
+		IPv6Manifest:    "/etc/kubernetes/ovn-kubernetes_ipv6.yaml",
+		SkipKubeProxy:   true,
+		Workloads: []Workload{
+			{Kind: "DaemonSet", Name: "ovs-node", Namespace: "ovn-kubernetes"},
+			{Kind: "DaemonSet", Name: "ovnkube-node", Namespace: "ovn-kubernetes"},
+			{Kind: "Deployment", Name: "ovnkube-control-plane", Namespace: "ovn-kubernetes"},
+		},
+	})
+}
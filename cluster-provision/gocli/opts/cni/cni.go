@@ -0,0 +1,101 @@
+package cni
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+// DefaultName is the CNI clusters are created with unless another one is selected
+const DefaultName = "calico"
+
+// Workload is a DaemonSet or Deployment of a CNI
+type Workload struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+// CNI describes how node01 sets up a CNI, the kubeadm configs and manifests are files the provider image ships
+type CNI struct {
+	Name string
+	// KubeadmConf and IPv6KubeadmConf hold the pod subnet settings the CNI needs, for dual stack and single stack
+	// IPv6 clusters
+	KubeadmConf     string
+	IPv6KubeadmConf string
+	Manifest        string
+	IPv6Manifest    string
+	// SkipKubeProxy initializes the cluster without kube-proxy, for CNIs which implement services on their own
+	SkipKubeProxy bool
+	// PostInstall are commands run on node01 once the manifest is created
+	PostInstall []string
+	// Workloads are rolled out on all nodes once the CNI is ready
+	Workloads []Workload
+}
+
+var registry = map[string]*CNI{}
+
+// Register makes a CNI selectable by its name
+func Register(c *CNI) {
+	if _, exists := registry[c.Name]; exists {
+		panic(fmt.Sprintf("CNI %s is registered twice", c.Name))
+	}
+	registry[c.Name] = c
+}
+
+// Get returns the registered CNI with the given name
+func Get(name string) (*CNI, error) {
+	c, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown CNI %s, supported are %s", name, strings.Join(Names(), ", "))
+	}
+	return c, nil
+}
+
+// Names returns the names of all registered CNIs
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KubeadmConfFor returns the kubeadm config node01 is initialized with
+func (c *CNI) KubeadmConfFor(singleStack bool) string {
+	if singleStack {
+		return c.IPv6KubeadmConf
+	}
+	return c.KubeadmConf
+}
+
+// ManifestFor returns the manifest node01 creates the CNI from
+func (c *CNI) ManifestFor(singleStack bool) string {
+	if singleStack {
+		return c.IPv6Manifest
+	}
+	return c.Manifest
+}
+
+// WaitReady waits for all workloads of the CNI to roll out
+func (c *CNI) WaitReady(ctx context.Context, client k8s.K8sDynamicClient) error {
+	for _, w := range c.Workloads {
+		var err error
+		switch w.Kind {
+		case "DaemonSet":
+			err = k8s.WaitForDaemonSetRollout(ctx, client, w.Name, w.Namespace)
+		case "Deployment":
+			err = k8s.WaitForDeploymentRollout(ctx, client, w.Name, w.Namespace)
+		default:
+			err = fmt.Errorf("can not wait for %s %s/%s, only DaemonSets and Deployments are supported", w.Kind, w.Namespace, w.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("error waiting for the %s CNI: %w", c.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cni
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestCNI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CNI Suite")
+}
+
+var _ = Describe("CNI registry", func() {
+	It("should register the built-in CNIs", func() {
+		Expect(Names()).To(Equal([]string{"calico", "cilium", "flannel", "ovn-kubernetes"}))
+		Expect(Names()).To(ContainElement(DefaultName))
+	})
+
+	It("should fail on unknown CNIs", func() {
+		_, err := Get("weave")
+		Expect(err).To(MatchError("unknown CNI weave, supported are calico, cilium, flannel, ovn-kubernetes"))
+	})
+
+	It("should not register a CNI twice", func() {
+		Expect(func() { Register(&CNI{Name: "calico"}) }).To(Panic())
+	})
+
+	It("should pick the kubeadm config and manifest of the IP family", func() {
+		flannel, err := Get("flannel")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(flannel.KubeadmConfFor(false)).To(Equal("/etc/kubernetes/kubeadm_flannel.conf"))
+		Expect(flannel.KubeadmConfFor(true)).To(Equal("/etc/kubernetes/kubeadm_flannel_ipv6.conf"))
+		Expect(flannel.ManifestFor(false)).To(Equal("/etc/kubernetes/flannel.yaml"))
+		Expect(flannel.ManifestFor(true)).To(Equal("/etc/kubernetes/flannel_ipv6.yaml"))
+	})
+})
+
+var _ = Describe("WaitReady", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	It("should wait for the workloads of the CNI", func() {
+		for _, kind := range []string{"DaemonSet", "Deployment"} {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("apps/v1")
+			obj.SetKind(kind)
+			obj.SetName("test-cni")
+			obj.SetNamespace("kube-system")
+			Expect(client.Apply(obj)).To(Succeed())
+		}
+		c := &CNI{
+			Name: "test",
+			Workloads: []Workload{
+				{Kind: "DaemonSet", Name: "test-cni", Namespace: "kube-system"},
+				{Kind: "Deployment", Name: "test-cni", Namespace: "kube-system"},
+			},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		Expect(c.WaitReady(ctx, client)).To(Succeed())
+	})
+
+	It("should fail on workloads which can not be waited for", func() {
+		c := &CNI{
+			Name:      "test",
+			Workloads: []Workload{{Kind: "StatefulSet", Name: "test-cni", Namespace: "kube-system"}},
+		}
+		Expect(c.WaitReady(context.Background(), client)).To(MatchError(ContainSubstring("only DaemonSets and Deployments are supported")))
+	})
+})
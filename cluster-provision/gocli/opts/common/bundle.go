@@ -0,0 +1,366 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// crdEstablishedTimeout is how long applying a bundle waits for its CRDs to be served before the custom resources are applied
+	crdEstablishedTimeout = 2 * time.Minute
+	// deletionTimeout is how long deleting a bundle waits for finalizers of custom resources and namespaces to finish
+	deletionTimeout = 5 * time.Minute
+)
+
+// installOrder lists the kinds which other objects depend on, they are applied in this order.
+// Kinds which are not listed, usually custom resources, are applied last
+var installOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"PriorityClass",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicaSet",
+	"Deployment",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// Mutator changes an object of a bundle before it is applied
+type Mutator func(obj *unstructured.Unstructured) error
+
+// Bundle is a set of manifests which are applied together
+type Bundle struct {
+	objects []*unstructured.Unstructured
+}
+
+// LoadBundle reads all .yaml files below the given paths of fsys, paths can either be files or directories
+func LoadBundle(fsys fs.FS, paths ...string) (*Bundle, error) {
+	b := &Bundle{}
+	for _, root := range paths {
+		err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".yaml" {
+				return nil
+			}
+
+			data, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+			return b.add(path, data)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// ParseBundle creates a bundle from multi document manifests
+func ParseBundle(manifests ...[]byte) (*Bundle, error) {
+	b := &Bundle{}
+	for i, manifest := range manifests {
+		if err := b.add(fmt.Sprintf("manifest %d", i), manifest); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (b *Bundle) add(source string, data []byte) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading document %d of %s: %w", i, source, err)
+		}
+
+		empty, err := isEmptyDocument(doc)
+		if err != nil {
+			return fmt.Errorf("error parsing document %d of %s: %w", i, source, err)
+		}
+		if empty {
+			continue
+		}
+
+		obj, err := k8s.SerializeIntoObject(doc)
+		if err != nil {
+			return fmt.Errorf("error parsing document %d of %s: %w", i, source, err)
+		}
+
+		if !obj.IsList() {
+			b.objects = append(b.objects, obj)
+			continue
+		}
+		err = obj.EachListItem(func(item runtime.Object) error {
+			b.objects = append(b.objects, item.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error parsing list in document %d of %s: %w", i, source, err)
+		}
+	}
+}
+
+// isEmptyDocument reports documents which only consist of comments or whitespace
+func isEmptyDocument(doc []byte) (bool, error) {
+	jsonData, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return false, err
+	}
+	return string(bytes.TrimSpace(jsonData)) == "null", nil
+}
+
+// Objects returns the objects of the bundle in the order they were read
+func (b *Bundle) Objects() []*unstructured.Unstructured {
+	return b.objects
+}
+
+// With adds objects which were not read from manifests to the bundle
+func (b *Bundle) With(objects ...*unstructured.Unstructured) *Bundle {
+	b.objects = append(b.objects, objects...)
+	return b
+}
+
+// Without drops all objects of the given kinds from the bundle
+func (b *Bundle) Without(kinds ...string) *Bundle {
+	objects := make([]*unstructured.Unstructured, 0, len(b.objects))
+	for _, obj := range b.objects {
+		if !contains(kinds, obj.GetKind()) {
+			objects = append(objects, obj)
+		}
+	}
+	b.objects = objects
+	return b
+}
+
+// Mutate runs the mutators on every object of the bundle
+func (b *Bundle) Mutate(mutators ...Mutator) error {
+	for _, obj := range b.objects {
+		for _, mutate := range mutators {
+			if err := mutate(obj); err != nil {
+				return fmt.Errorf("error mutating %s %s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply applies all objects in install order. Once all CRDs of the bundle are applied it waits for them
+// to be established, so custom resources of the same bundle can be applied right away
+func (b *Bundle) Apply(client k8s.K8sDynamicClient) error {
+	objects := make([]*unstructured.Unstructured, len(b.objects))
+	copy(objects, b.objects)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return installRank(objects[i]) < installRank(objects[j])
+	})
+
+	crds := []string{}
+	for i, obj := range objects {
+		if err := client.Apply(obj); err != nil {
+			return err
+		}
+
+		if obj.GetKind() == "CustomResourceDefinition" {
+			crds = append(crds, obj.GetName())
+		}
+		lastCRD := len(crds) > 0 && (i+1 == len(objects) || objects[i+1].GetKind() != "CustomResourceDefinition")
+		if lastCRD {
+			if err := waitForCRDs(client, crds); err != nil {
+				return err
+			}
+			crds = nil
+		}
+	}
+	return nil
+}
+
+// Delete removes all objects in reverse install order, objects which do not exist are skipped.
+// Custom resources are deleted first and waited for, so their operators can still run the finalizers,
+// namespaces are deleted last and waited for as well, so the bundle can be applied again right away
+func (b *Bundle) Delete(client k8s.K8sDynamicClient) error {
+	objects := make([]*unstructured.Unstructured, len(b.objects))
+	copy(objects, b.objects)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return installRank(objects[i]) > installRank(objects[j])
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), deletionTimeout)
+	defer cancel()
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		err := client.Delete(gvk, obj.GetName(), obj.GetNamespace())
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error deleting %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+
+		if installRank(obj) == len(installOrder) || obj.GetKind() == "Namespace" {
+			if err := k8s.WaitForDeletion(ctx, client, gvk, obj.GetName(), obj.GetNamespace()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func waitForCRDs(client k8s.K8sDynamicClient, names []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), crdEstablishedTimeout)
+	defer cancel()
+	for _, name := range names {
+		if err := k8s.WaitForCRDEstablished(ctx, client, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func installRank(obj *unstructured.Unstructured) int {
+	for i, kind := range installOrder {
+		if obj.GetKind() == kind {
+			return i
+		}
+	}
+	return len(installOrder)
+}
+
+// WithImageTag sets the tag of the container images whose name starts with namePrefix, and of the *_IMAGE and
+// OPERATOR_VERSION environment variables operators use to deploy their components, to tag. Images of other projects,
+// e.g. sidecars, keep their tags
+func WithImageTag(tag, namePrefix string) Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		for _, path := range [][]string{
+			{"spec", "template", "spec", "containers"},
+			{"spec", "template", "spec", "initContainers"},
+		} {
+			containers, found, err := unstructured.NestedSlice(obj.Object, path...)
+			if err != nil || !found {
+				continue
+			}
+
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if image, ok := container["image"].(string); ok && strings.HasPrefix(imageName(image), namePrefix) {
+					container["image"] = replaceTag(image, tag)
+				}
+
+				env, _ := container["env"].([]interface{})
+				for _, e := range env {
+					envVar, ok := e.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					name, _ := envVar["name"].(string)
+					value, ok := envVar["value"].(string)
+					switch {
+					case !ok:
+					case name == "OPERATOR_VERSION":
+						envVar["value"] = tag
+					case strings.HasSuffix(name, "_IMAGE") && strings.HasPrefix(imageName(value), namePrefix):
+						envVar["value"] = replaceTag(value, tag)
+					}
+				}
+			}
+
+			if err := unstructured.SetNestedSlice(obj.Object, containers, path...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// imageName returns the last path component of an image reference, without its tag and digest
+func imageName(image string) string {
+	name := strings.TrimSuffix(replaceTag(image, ""), ":")
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// replaceTag drops the digest and tag of an image reference and sets tag, the ":" of a digest or of a
+// registry port must not be taken for the one of a tag
+func replaceTag(image, tag string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + ":" + tag
+}
+
+// WithNamespace moves all namespaced objects of a bundle deploying into a single namespace to ns,
+// Namespace objects are renamed to ns
+func WithNamespace(ns string) Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		if obj.GetKind() == "Namespace" {
+			obj.SetName(ns)
+		} else if obj.GetNamespace() != "" {
+			obj.SetNamespace(ns)
+		}
+		return nil
+	}
+}
+
+// WithoutField removes the field from all objects of the given kind, e.g. to disable a component of an operator CR
+func WithoutField(kind string, fields ...string) Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		if obj.GetKind() == kind {
+			unstructured.RemoveNestedField(obj.Object, fields...)
+		}
+		return nil
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package common
+
+import (
+	"testing"
+	"testing/fstest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestCommon(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Common Suite")
+}
+
+const operatorManifests = `# operator of the widget addon
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  multus: {}
+  bridge: {}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: widget-operator
+  namespace: widgets
+spec:
+  template:
+    spec:
+      containers:
+      - name: operator
+        image: quay.io/example/widget-operator:v1.0.0
+        env:
+        - name: OPERATOR_VERSION
+          value: v1.0.0
+        - name: CONTROLLER_IMAGE
+          value: registry:5000/widget-controller:v1.0.0
+        - name: PROXY_IMAGE
+          value: quay.io/brancz/kube-rbac-proxy:v0.18.0
+        - name: VERBOSITY
+          value: "1"
+      - name: proxy
+        image: quay.io/brancz/kube-rbac-proxy:v0.18.0
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: widgets
+`
+
+var _ = Describe("Bundle", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	kinds := func(b *Bundle) []string {
+		kinds := []string{}
+		for _, obj := range b.Objects() {
+			kinds = append(kinds, obj.GetKind())
+		}
+		return kinds
+	}
+
+	It("should skip documents which only contain comments", func() {
+		bundle, err := ParseBundle([]byte(operatorManifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kinds(bundle)).To(Equal([]string{"Widget", "Deployment", "CustomResourceDefinition", "Namespace"}))
+	})
+
+	It("should fail on documents which can not be parsed", func() {
+		_, err := ParseBundle([]byte(operatorManifests + "---\nmetadata:\n  name: no-kind\n"))
+		Expect(err).To(MatchError(ContainSubstring("error parsing document 5 of manifest 0")))
+	})
+
+	It("should load all yaml files below the given directories", func() {
+		fsys := fstest.MapFS{
+			"manifests/operator/operator.yaml": {Data: []byte(operatorManifests)},
+			"manifests/operator/README.md":     {Data: []byte("# not a manifest")},
+			"manifests/cr/list.yaml": {Data: []byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: first
+    namespace: widgets
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: second
+    namespace: widgets
+`)},
+		}
+
+		bundle, err := LoadBundle(fsys, "manifests/cr", "manifests/operator")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(kinds(bundle)).To(Equal([]string{"ConfigMap", "ConfigMap", "Widget", "Deployment", "CustomResourceDefinition", "Namespace"}))
+	})
+
+	It("should apply namespaces and CRDs before the objects depending on them", func() {
+		applied := []string{}
+		client = k8s.NewTestClient(k8s.NewReactorConfig("create", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			applied = append(applied, action.GetResource().Resource)
+			return false, nil, nil
+		}))
+
+		bundle, err := ParseBundle([]byte(operatorManifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Apply(client)).To(Succeed())
+
+		Expect(applied).To(Equal([]string{"namespaces", "customresourcedefinitions", "deployments", "widgets"}))
+	})
+
+	It("should set the tag of the images of the operator", func() {
+		bundle, err := ParseBundle([]byte(operatorManifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Mutate(WithImageTag("v2.0.0-rc.1", "widget-"))).To(Succeed())
+
+		containers, _, err := unstructured.NestedSlice(bundle.Objects()[1].Object, "spec", "template", "spec", "containers")
+		Expect(err).NotTo(HaveOccurred())
+		container := containers[0].(map[string]interface{})
+		Expect(container["image"]).To(Equal("quay.io/example/widget-operator:v2.0.0-rc.1"))
+		Expect(container["env"]).To(Equal([]interface{}{
+			map[string]interface{}{"name": "OPERATOR_VERSION", "value": "v2.0.0-rc.1"},
+			map[string]interface{}{"name": "CONTROLLER_IMAGE", "value": "registry:5000/widget-controller:v2.0.0-rc.1"},
+			map[string]interface{}{"name": "PROXY_IMAGE", "value": "quay.io/brancz/kube-rbac-proxy:v0.18.0"},
+			map[string]interface{}{"name": "VERBOSITY", "value": "1"},
+		}))
+
+		By("keeping the tags of the images of other projects")
+		Expect(containers[1].(map[string]interface{})["image"]).To(Equal("quay.io/brancz/kube-rbac-proxy:v0.18.0"))
+	})
+
+	It("should replace the digest of images pinned by digest", func() {
+		bundle, err := ParseBundle([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: pinned
+spec:
+  template:
+    spec:
+      containers:
+      - name: pinned
+        image: registry:5000/pinned@sha256:0123456789abcdef
+      - name: tagged
+        image: quay.io/example/tagged:v1.0.0@sha256:0123456789abcdef
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Mutate(WithImageTag("v2.0.0", ""))).To(Succeed())
+
+		containers, _, err := unstructured.NestedSlice(bundle.Objects()[0].Object, "spec", "template", "spec", "containers")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers[0].(map[string]interface{})["image"]).To(Equal("registry:5000/pinned:v2.0.0"))
+		Expect(containers[1].(map[string]interface{})["image"]).To(Equal("quay.io/example/tagged:v2.0.0"))
+	})
+
+	It("should drop kinds and fields which are disabled", func() {
+		bundle, err := ParseBundle([]byte(operatorManifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Mutate(WithoutField("Widget", "spec", "multus"), WithNamespace("other"))).To(Succeed())
+		Expect(bundle.Apply(client)).To(Succeed())
+
+		widget, err := client.Get(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, "widget", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(widget.Object["spec"]).To(Equal(map[string]interface{}{"bridge": map[string]interface{}{}}))
+		_, err = client.Get(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "widget-operator", "other")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(kinds(bundle.Without("Widget", "Namespace"))).To(Equal([]string{"Deployment", "CustomResourceDefinition"}))
+	})
+
+	It("should delete custom resources first and namespaces last", func() {
+		deleted := []string{}
+		client = k8s.NewTestClient(k8s.NewReactorConfig("delete", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			deleted = append(deleted, action.GetResource().Resource)
+			return false, nil, nil
+		}))
+
+		bundle, err := ParseBundle([]byte(operatorManifests))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundle.Apply(client)).To(Succeed())
+		Expect(bundle.Delete(client)).To(Succeed())
+		Expect(deleted).To(Equal([]string{"widgets", "deployments", "customresourcedefinitions", "namespaces"}))
+
+		_, err = client.Get(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "widget-operator", "widgets")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		By("skipping objects which are already gone")
+		Expect(bundle.Delete(client)).To(Succeed())
+	})
+})
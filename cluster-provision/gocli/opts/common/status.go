@@ -0,0 +1,27 @@
+package common
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+// CheckStatus reports an addon as installed if the object exists, and as ready if ready reports the object as ready.
+// Objects whose kind is not served, because the CRDs of the addon are not installed, count as not installed
+func CheckStatus(client k8s.K8sDynamicClient, gvk schema.GroupVersionKind, name, ns string, ready k8s.ReadyFunc) (opts.Status, error) {
+	obj, err := client.Get(gvk, name, ns)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return opts.Status{}, nil
+	}
+	if err != nil {
+		return opts.Status{}, err
+	}
+
+	isReady, reason, err := ready(obj)
+	if err != nil {
+		return opts.Status{}, err
+	}
+	return opts.Status{Installed: true, Ready: isReady, Message: reason}, nil
+}
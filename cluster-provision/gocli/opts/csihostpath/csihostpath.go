@@ -0,0 +1,137 @@
+package csihostpath
+
+import (
+	"context"
+	"embed"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/snapshotcontroller"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/*
+var f embed.FS
+
+const (
+	// StorageClass provisions volumes with the CSI hostpath driver
+	StorageClass = "csi-hostpath"
+	// SnapshotClass snapshots volumes of the StorageClass
+	SnapshotClass = "csi-hostpath-snapclass"
+
+	namespace = "csi-hostpath"
+	// localStorageClass is the default StorageClass of the provider
+	localStorageClass = "local"
+
+	defaultStorageClassAnnotation  = "storageclass.kubernetes.io/is-default-class"
+	defaultSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+type csiHostpathOpt struct {
+	client      k8s.K8sDynamicClient
+	makeDefault bool
+}
+
+// NewCsiHostpathOpt creates an opt which deploys the CSI hostpath driver on top of the snapshot controller, with makeDefault
+// its StorageClass and VolumeSnapshotClass become the default ones of the cluster
+func NewCsiHostpathOpt(c k8s.K8sDynamicClient, makeDefault bool) *csiHostpathOpt {
+	return &csiHostpathOpt{
+		client:      c,
+		makeDefault: makeDefault,
+	}
+}
+
+func (o *csiHostpathOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "csi-hostpath", Dependencies: []string{snapshotcontroller.Name}}
+}
+
+func (o *csiHostpathOpt) Exec() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	if o.makeDefault {
+		if err := bundle.Mutate(withDefaultClasses); err != nil {
+			return err
+		}
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := k8s.WaitForStatefulSetRollout(ctx, o.client, "csi-hostpathplugin", namespace); err != nil {
+		return err
+	}
+
+	if o.makeDefault {
+		return setDefault(o.client, localStorageClass, false)
+	}
+	return nil
+}
+
+// Uninstall makes local the default StorageClass again if csi-hostpath was the default one
+func (o *csiHostpathOpt) Uninstall() error {
+	storageClass, err := o.client.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), StorageClass, "")
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && storageClass.GetAnnotations()[defaultStorageClassAnnotation] == "true" {
+		if err := setDefault(o.client, localStorageClass, true); err != nil {
+			return err
+		}
+	}
+
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *csiHostpathOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("StatefulSet"), "csi-hostpathplugin", namespace, k8s.StatefulSetRolledOut)
+}
+
+// withDefaultClasses marks the StorageClass and VolumeSnapshotClass of the driver as the default ones
+func withDefaultClasses(obj *unstructured.Unstructured) error {
+	switch {
+	case obj.GetKind() == "StorageClass" && obj.GetName() == StorageClass:
+		setAnnotation(obj, defaultStorageClassAnnotation, "true")
+	case obj.GetKind() == "VolumeSnapshotClass" && obj.GetName() == SnapshotClass:
+		setAnnotation(obj, defaultSnapshotClassAnnotation, "true")
+	}
+	return nil
+}
+
+// setDefault marks a StorageClass as default or not, StorageClasses which do not exist are skipped
+func setDefault(client k8s.K8sDynamicClient, name string, isDefault bool) error {
+	storageClass, err := client.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), name, "")
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	value := "false"
+	if isDefault {
+		value = "true"
+	}
+	setAnnotation(storageClass, defaultStorageClassAnnotation, value)
+	return client.Update(storageClass)
+}
+
+func setAnnotation(obj *unstructured.Unstructured, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,76 @@
+package csihostpath
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestCsiHostpathOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CsiHostpathOpt Suite")
+}
+
+var _ = Describe("CsiHostpathOpt", func() {
+	var client k8s.K8sDynamicClient
+
+	storageClassGVK := storagev1.SchemeGroupVersion.WithKind("StorageClass")
+	snapshotClassGVK := schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshotClass"}
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+		local := &unstructured.Unstructured{}
+		local.SetAPIVersion("storage.k8s.io/v1")
+		local.SetKind("StorageClass")
+		local.SetName(localStorageClass)
+		local.SetAnnotations(map[string]string{defaultStorageClassAnnotation: "true"})
+		Expect(client.Apply(local)).To(Succeed())
+	})
+
+	isDefault := func(gvk schema.GroupVersionKind, name, annotation string) string {
+		obj, err := client.Get(gvk, name, "")
+		Expect(err).NotTo(HaveOccurred())
+		return obj.GetAnnotations()[annotation]
+	}
+
+	It("should deploy the driver", func() {
+		opt := NewCsiHostpathOpt(client, false)
+		Expect(opt.Exec()).To(Succeed())
+
+		_, err := client.Get(appsv1.SchemeGroupVersion.WithKind("StatefulSet"), "csi-hostpathplugin", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isDefault(storageClassGVK, StorageClass, defaultStorageClassAnnotation)).To(BeEmpty())
+		Expect(isDefault(snapshotClassGVK, SnapshotClass, defaultSnapshotClassAnnotation)).To(BeEmpty())
+		Expect(isDefault(storageClassGVK, localStorageClass, defaultStorageClassAnnotation)).To(Equal("true"))
+
+		snapshotClass, err := client.Get(snapshotClassGVK, SnapshotClass, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshotClass.GetLabels()).To(HaveKeyWithValue("velero.io/csi-volumesnapshot-class", "true"))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+	})
+
+	It("should make the StorageClass and VolumeSnapshotClass the default ones until it is uninstalled", func() {
+		opt := NewCsiHostpathOpt(client, true)
+		Expect(opt.Exec()).To(Succeed())
+
+		Expect(isDefault(storageClassGVK, StorageClass, defaultStorageClassAnnotation)).To(Equal("true"))
+		Expect(isDefault(snapshotClassGVK, SnapshotClass, defaultSnapshotClassAnnotation)).To(Equal("true"))
+		Expect(isDefault(storageClassGVK, localStorageClass, defaultStorageClassAnnotation)).To(Equal("false"))
+
+		Expect(opt.Uninstall()).To(Succeed())
+		Expect(isDefault(storageClassGVK, localStorageClass, defaultStorageClassAnnotation)).To(Equal("true"))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+})
@@ -0,0 +1,127 @@
+package dra
+
+import (
+	"context"
+	_ "embed"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+)
+
+//go:embed manifests/dra-example-driver.yaml
+var driver []byte
+
+// DefaultDevices is the number of emulated devices the driver advertises on every node
+const DefaultDevices = 8
+
+const namespace = "dra-example-driver"
+
+type draOpt struct {
+	client  k8s.K8sDynamicClient
+	nodes   []libssh.Client
+	devices int
+}
+
+// NewDraOpt creates an opt which deploys the DRA example driver, it advertises the given number of emulated devices
+// of the gpu.example.com DeviceClass on every node. The PCI devices which are bound to vfio-pci on the nodes, like the
+// sound cards, are published as devices of the example driver too, which the PCIDeviceClass selects. nodes are the
+// ssh clients of all nodes
+func NewDraOpt(c k8s.K8sDynamicClient, nodes []libssh.Client, devices int) *draOpt {
+	return &draOpt{
+		client:  c,
+		nodes:   nodes,
+		devices: devices,
+	}
+}
+
+// Descriptor requires Kubernetes 1.34, which serves the resource.k8s.io/v1 API and enables DRA by default
+func (o *draOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "dra", KubernetesVersion: ">=1.34"}
+}
+
+func (o *draOpt) Exec() error {
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	if err := k8s.WaitForDaemonSetRollout(ctx, o.client, "dra-example-driver-kubeletplugin", namespace); err != nil {
+		return err
+	}
+
+	pci, err := o.pciBundle(true)
+	if err != nil {
+		return err
+	}
+	return pci.Apply(o.client)
+}
+
+func (o *draOpt) Uninstall() error {
+	pci, err := o.pciBundle(false)
+	if err != nil {
+		return err
+	}
+	if err := pci.Delete(o.client); err != nil {
+		return err
+	}
+
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *draOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "dra-example-driver-kubeletplugin", namespace, k8s.DaemonSetRolledOut)
+}
+
+func (o *draOpt) bundle() (*common.Bundle, error) {
+	bundle, err := common.ParseBundle(driver)
+	if err != nil {
+		return nil, err
+	}
+	if err := bundle.Mutate(withDevices(o.devices)); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// withDevices sets the number of devices in the NUM_DEVICES variable of the kubelet plugin
+func withDevices(devices int) common.Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		if obj.GetKind() != "DaemonSet" {
+			return nil
+		}
+		containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		if err != nil {
+			return err
+		}
+		for _, container := range containers {
+			env, _, err := unstructured.NestedSlice(container.(map[string]interface{}), "env")
+			if err != nil {
+				return err
+			}
+			for _, variable := range env {
+				if variable.(map[string]interface{})["name"] == "NUM_DEVICES" {
+					variable.(map[string]interface{})["value"] = strconv.Itoa(devices)
+				}
+			}
+			if err := unstructured.SetNestedSlice(container.(map[string]interface{}), env, "env"); err != nil {
+				return err
+			}
+		}
+		return unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+	}
+}
@@ -0,0 +1,122 @@
+package dra
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func TestDraOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DraOpt Suite")
+}
+
+var _ = Describe("DraOpt", func() {
+	var (
+		client k8s.K8sDynamicClient
+		node01 *kubevirtcimocks.MockSSHClient
+		node02 *kubevirtcimocks.MockSSHClient
+	)
+
+	resourceSliceGVK := schema.GroupVersionKind{Group: "resource.k8s.io", Version: "v1", Kind: "ResourceSlice"}
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+		mockCtrl := gomock.NewController(GinkgoT())
+		node01 = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		node02 = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+
+		for _, name := range []string{"node01", "node02"} {
+			node := &unstructured.Unstructured{}
+			node.SetAPIVersion("v1")
+			node.SetKind("Node")
+			node.SetName(name)
+			node.SetLabels(map[string]string{"kubernetes.io/hostname": name})
+			Expect(client.Apply(node)).To(Succeed())
+		}
+	})
+
+	It("should deploy the example driver with the given number of devices", func() {
+		node01.EXPECT().CommandWithNoStdOut(listVfioDevices).Return("node01\n", nil)
+		node02.EXPECT().CommandWithNoStdOut(listVfioDevices).Return("node02\n", nil)
+
+		opt := NewDraOpt(client, []libssh.Client{node01, node02}, 3)
+		Expect(opt.Exec()).To(Succeed())
+
+		_, err := client.Get(schema.GroupVersionKind{Group: "resource.k8s.io", Version: "v1", Kind: "DeviceClass"}, "gpu.example.com", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		daemonSet, err := client.Get(appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "dra-example-driver-kubeletplugin", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		containers, _, err := unstructured.NestedSlice(daemonSet.Object, "spec", "template", "spec", "containers")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers[0].(map[string]interface{})["env"]).To(ContainElement(map[string]interface{}{"name": "NUM_DEVICES", "value": "3"}))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err = opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+
+	It("should publish the PCI devices bound to vfio-pci as devices of the example driver", func() {
+		// the ssh clients need not be in the order of the nodes
+		node01.EXPECT().CommandWithNoStdOut(listVfioDevices).Return("node02\n", nil)
+		node02.EXPECT().CommandWithNoStdOut(listVfioDevices).Return("node01\n0000:00:1b.0 0x8086 0x293e 7\n", nil)
+
+		opt := NewDraOpt(client, []libssh.Client{node01, node02}, DefaultDevices)
+		Expect(opt.Exec()).To(Succeed())
+
+		_, err := client.Get(schema.GroupVersionKind{Group: "resource.k8s.io", Version: "v1", Kind: "DeviceClass"}, PCIDeviceClass, "")
+		Expect(err).NotTo(HaveOccurred())
+		slice, err := client.Get(resourceSliceGVK, "node01-vfio-pci", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slice.Object["spec"]).To(HaveKeyWithValue("driver", "gpu.example.com"))
+		Expect(slice.Object["spec"]).NotTo(HaveKey("nodeName"))
+		Expect(slice.Object["spec"]).To(HaveKeyWithValue("nodeSelector", map[string]interface{}{
+			"nodeSelectorTerms": []interface{}{
+				map[string]interface{}{
+					"matchFields": []interface{}{
+						map[string]interface{}{"key": "metadata.name", "operator": "In", "values": []interface{}{"node01"}},
+					},
+				},
+			},
+		}))
+		devices, _, err := unstructured.NestedSlice(slice.Object, "spec", "devices")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(devices).To(ConsistOf(map[string]interface{}{
+			"name": "gpu-0",
+			"attributes": map[string]interface{}{
+				PCIBusIDAttribute: map[string]interface{}{"string": "0000:00:1b.0"},
+				"vendor":          map[string]interface{}{"string": "8086"},
+				"device":          map[string]interface{}{"string": "293e"},
+				"iommuGroup":      map[string]interface{}{"int": int64(7)},
+			},
+		}))
+		_, err = client.Get(resourceSliceGVK, "node02-vfio-pci", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		_, err = client.Get(resourceSliceGVK, "node01-vfio-pci", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should refuse more PCI devices than the example driver prepares", func() {
+		node01.EXPECT().CommandWithNoStdOut(listVfioDevices).Return("node01\n0000:00:1b.0 0x8086 0x293e 7\n0000:00:1c.0 0x8086 0x2668 8\n", nil)
+
+		opt := NewDraOpt(client, []libssh.Client{node01}, 1)
+		Expect(opt.Exec()).To(MatchError("node01 has 2 devices bound to vfio-pci, the example driver prepares at most 1 devices per node"))
+	})
+})
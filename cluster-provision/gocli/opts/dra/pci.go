@@ -0,0 +1,163 @@
+package dra
+
+import (
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+)
+
+//go:embed manifests/pci.yaml
+var pciDeviceClass []byte
+
+const (
+	// PCIDeviceClass selects the PCI devices which are bound to vfio-pci on the nodes, like the sound cards
+	PCIDeviceClass = "vfio-pci.example.com"
+	// PCIBusIDAttribute is the standard attribute KubeVirt reads the PCI address of an allocated device from
+	PCIBusIDAttribute = "resource.kubernetes.io/pciBusID"
+
+	// exampleDriver is the driver of the example kubelet plugin, which prepares the PCI devices as well
+	exampleDriver = "gpu.example.com"
+
+	// listVfioDevices prints the hostname of the node, followed by the address, vendor, device and IOMMU group of
+	// every device bound to vfio-pci
+	listVfioDevices = `hostname; for dev in /sys/bus/pci/drivers/vfio-pci/*:*; do [ -e "$dev" ] || continue; ` +
+		`echo "$(basename $dev) $(cat $dev/vendor) $(cat $dev/device) $(basename $(readlink $dev/iommu_group))"; done`
+)
+
+var nodeGVK = schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+
+// pciDevice is a PCI device bound to vfio-pci
+type pciDevice struct {
+	address    string
+	vendor     string
+	device     string
+	iommuGroup int64
+}
+
+// vfioDevices returns the hostname of the node and the PCI devices which are bound to vfio-pci on it
+func vfioDevices(sshClient libssh.Client) (string, []pciDevice, error) {
+	out, err := sshClient.CommandWithNoStdOut(listVfioDevices)
+	if err != nil {
+		return "", nil, fmt.Errorf("error listing the devices bound to vfio-pci: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	hostname := strings.TrimSpace(lines[0])
+	devices := []pciDevice{}
+	for _, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return "", nil, fmt.Errorf("unexpected device %q", line)
+		}
+		iommuGroup, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid IOMMU group of device %s: %w", fields[0], err)
+		}
+		devices = append(devices, pciDevice{
+			address:    fields[0],
+			vendor:     strings.TrimPrefix(fields[1], "0x"),
+			device:     strings.TrimPrefix(fields[2], "0x"),
+			iommuGroup: iommuGroup,
+		})
+	}
+	return hostname, devices, nil
+}
+
+// resourceSlice publishes the devices of a node as the only slice of a pool of the example driver. The example
+// kubelet plugin only prepares devices with the names of its emulated ones, so the devices are named alike. The
+// slices of the driver which have the nodeName of a node belong to the plugin, which deletes those it did not publish
+// itself, so the slice selects the node with a node selector instead
+func resourceSlice(nodeName string, devices []pciDevice) *unstructured.Unstructured {
+	entries := []interface{}{}
+	for i, d := range devices {
+		entries = append(entries, map[string]interface{}{
+			"name": fmt.Sprintf("gpu-%d", i),
+			"attributes": map[string]interface{}{
+				PCIBusIDAttribute: map[string]interface{}{"string": d.address},
+				"vendor":          map[string]interface{}{"string": d.vendor},
+				"device":          map[string]interface{}{"string": d.device},
+				"iommuGroup":      map[string]interface{}{"int": d.iommuGroup},
+			},
+		})
+	}
+
+	slice := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"driver": exampleDriver,
+			"nodeSelector": map[string]interface{}{
+				"nodeSelectorTerms": []interface{}{
+					map[string]interface{}{
+						"matchFields": []interface{}{
+							map[string]interface{}{"key": "metadata.name", "operator": "In", "values": []interface{}{nodeName}},
+						},
+					},
+				},
+			},
+			"pool": map[string]interface{}{
+				"name":               resourceSliceName(nodeName),
+				"generation":         int64(1),
+				"resourceSliceCount": int64(1),
+			},
+			"devices": entries,
+		},
+	}}
+	slice.SetAPIVersion("resource.k8s.io/v1")
+	slice.SetKind("ResourceSlice")
+	slice.SetName(resourceSliceName(nodeName))
+	return slice
+}
+
+func resourceSliceName(nodeName string) string {
+	return nodeName + "-vfio-pci"
+}
+
+// pciBundle holds the DeviceClass and the ResourceSlices of the nodes which have devices bound to vfio-pci, the
+// nodes are matched to the Node objects by their hostname. Without discover, the ResourceSlices of all nodes are
+// added with no devices, which is enough to delete them
+func (o *draOpt) pciBundle(discover bool) (*common.Bundle, error) {
+	bundle, err := common.ParseBundle(pciDeviceClass)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := o.client.List(nodeGVK, "")
+	if err != nil {
+		return nil, err
+	}
+	if !discover {
+		for _, node := range nodes.Items {
+			bundle.With(resourceSlice(node.GetName(), nil))
+		}
+		return bundle, nil
+	}
+
+	nodeNames := map[string]string{}
+	for _, node := range nodes.Items {
+		nodeNames[node.GetLabels()["kubernetes.io/hostname"]] = node.GetName()
+	}
+	for i, sshClient := range o.nodes {
+		hostname, devices, err := vfioDevices(sshClient)
+		if err != nil {
+			return nil, fmt.Errorf("error discovering the PCI devices of node %d: %w", i+1, err)
+		}
+		nodeName, exists := nodeNames[hostname]
+		if !exists {
+			return nil, fmt.Errorf("there is no node with the hostname %s", hostname)
+		}
+		if len(devices) > o.devices {
+			return nil, fmt.Errorf("%s has %d devices bound to vfio-pci, the example driver prepares at most %d devices per node", nodeName, len(devices), o.devices)
+		}
+		if len(devices) > 0 {
+			bundle.With(resourceSlice(nodeName, devices))
+		}
+	}
+	return bundle, nil
+}
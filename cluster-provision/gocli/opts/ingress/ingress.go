@@ -0,0 +1,114 @@
+package ingress
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/ingress-nginx.yaml
+var ingressNginx []byte
+
+//go:embed manifests/ingress-dns.yaml
+var ingressDNS []byte
+
+const (
+	// DefaultDomain is the wildcard domain resolved to the ingress, every subdomain of it is routed by the ingress rules
+	DefaultDomain = "kubevirtci.test"
+	// DefaultAddress is what the wildcard domain resolves to, the address of node01 which runs the ingress on its http
+	// and https ports
+	DefaultAddress = "192.168.66.101"
+)
+
+const namespace = "ingress-nginx"
+
+// corefile answers A queries of the domain and all its subdomains with the address, AAAA queries with no records
+const corefile = `.:1053 {
+    errors
+    template IN A %[1]s {
+        answer "{{ .Name }} 60 IN A %[2]s"
+    }
+    template IN AAAA %[1]s {
+        rcode NOERROR
+    }
+}
+`
+
+type ingressOpt struct {
+	client  k8s.K8sDynamicClient
+	domain  string
+	address string
+}
+
+// NewIngressOpt creates an opt which deploys ingress-nginx on the http and https ports of node01 and a DNS server on
+// the dns port of the cluster that resolves the domain and all its subdomains to the address
+func NewIngressOpt(c k8s.K8sDynamicClient, domain, address string) *ingressOpt {
+	return &ingressOpt{
+		client:  c,
+		domain:  domain,
+		address: address,
+	}
+}
+
+func (o *ingressOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "ingress"}
+}
+
+func (o *ingressOpt) Exec() error {
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	if err := k8s.WaitForDeploymentRollout(ctx, o.client, "ingress-nginx-controller", namespace); err != nil {
+		return err
+	}
+	if err := k8s.WaitForDeploymentRollout(ctx, o.client, "ingress-dns", namespace); err != nil {
+		return err
+	}
+	logrus.Infof("*.%s is routed by ingress-nginx", o.domain)
+	return nil
+}
+
+func (o *ingressOpt) Uninstall() error {
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *ingressOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "ingress-nginx-controller", namespace, k8s.DeploymentRolledOut)
+}
+
+// bundle holds the controller and the DNS server with the Corefile of the domain
+func (o *ingressOpt) bundle() (*common.Bundle, error) {
+	bundle, err := common.ParseBundle(ingressNginx, ingressDNS)
+	if err != nil {
+		return nil, err
+	}
+	err = bundle.Mutate(func(obj *unstructured.Unstructured) error {
+		if obj.GetKind() == "ConfigMap" && obj.GetName() == "ingress-dns" {
+			return unstructured.SetNestedField(obj.Object, fmt.Sprintf(corefile, o.domain, o.address), "data", "Corefile")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
@@ -0,0 +1,53 @@
+package ingress
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestIngressOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IngressOpt Suite")
+}
+
+var _ = Describe("IngressOpt", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	It("should deploy ingress-nginx with a DNS server for the wildcard domain", func() {
+		opt := NewIngressOpt(client, "apps.example.test", "192.168.66.101")
+		Expect(opt.Exec()).To(Succeed())
+
+		configMap, err := client.Get(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, "ingress-dns", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		config, _, err := unstructured.NestedString(configMap.Object, "data", "Corefile")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(ContainSubstring("template IN A apps.example.test {"))
+		Expect(config).To(ContainSubstring(`answer "{{ .Name }} 60 IN A 192.168.66.101"`))
+
+		_, err = client.Get(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "IngressClass"}, "nginx", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+	})
+
+	It("should remove ingress-nginx on uninstall", func() {
+		opt := NewIngressOpt(client, DefaultDomain, DefaultAddress)
+		Expect(opt.Exec()).To(Succeed())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+})
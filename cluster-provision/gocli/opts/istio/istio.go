@@ -1,16 +1,19 @@
 package istio
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
@@ -40,13 +43,17 @@ func NewIstioOpt(sc libssh.Client, c k8s.K8sDynamicClient, cnaoEnabled bool) *is
 	}
 }
 
-func (o *istioOpt) Exec() error {
-	obj, err := k8s.SerializeIntoObject(ns)
-	if err != nil {
-		return err
+func (o *istioOpt) Descriptor() opts.Descriptor {
+	deps := []string{}
+	if o.cnaoEnabled {
+		// istio CNI is chained into the multus configuration cnao deploys
+		deps = append(deps, "cnao")
 	}
+	return opts.Descriptor{Name: "istio", Dependencies: deps}
+}
 
-	if err := o.client.Apply(obj); err != nil {
+func (o *istioOpt) Exec() error {
+	if err := common.ApplyYAML(ns, o.client); err != nil {
 		return err
 	}
 
@@ -66,51 +73,74 @@ func (o *istioOpt) Exec() error {
 		}
 	}
 
+	// istioctl only returns once the CNI DaemonSet is ready, which needs the CNI container to be privileged,
+	// so the DaemonSet has to be patched while the install is still running
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	patchErr := make(chan error, 1)
 	go func() {
-		operation := func() error {
-			obj, err := o.client.Get(schema.GroupVersionKind{Group: "apps",
-				Version: "v1",
-				Kind:    "DaemonSet"}, "istio-cni-node", "kube-system")
-			if err != nil {
-				fmt.Printf("Error getting the CNI DaemonSet: %s\n", err.Error())
-				return err
-			}
-
-			cniDaemonSet := &appsv1.DaemonSet{}
-			err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, cniDaemonSet)
-			if err != nil {
-				fmt.Printf("Error converting the CNI DaemonSet: %s\n", err.Error())
-				return err
-			}
-
-			privileged := true
-			cniDaemonSet.Spec.Template.Spec.Containers[0].SecurityContext.Privileged = &privileged
-			newCniDaemonSet, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cniDaemonSet)
-			if err != nil {
-				fmt.Printf("Error converting the CNI DaemonSet: %s\n", err.Error())
-				return err
-			}
-
-			err = o.client.Update(&unstructured.Unstructured{Object: newCniDaemonSet})
-			if err != nil {
-				fmt.Printf("Error patching the CNI DaemonSet: %s\n", err.Error())
-				return err
-			}
-			return nil
-		}
-
-		backoffStrategy := backoff.NewExponentialBackOff()
-		backoffStrategy.InitialInterval = 10 * time.Second
-		backoffStrategy.MaxElapsedTime = 3 * time.Minute
-
-		_ = backoff.Retry(operation, backoffStrategy)
+		patchErr <- o.privilegeCNIDaemonSet(ctx)
 	}()
 
 	istioInstallCmd := "PATH=/opt/istio-" + istioVersion + "/bin:$PATH istioctl --kubeconfig /etc/kubernetes/admin.conf install -y -f " + istioFile
 	if err := o.sshClient.Command(istioInstallCmd); err != nil {
+		cancel()
+		<-patchErr
+		return err
+	}
+	if err := <-patchErr; err != nil {
 		return err
 	}
 
 	logrus.Info("Istio operator is now ready!")
 	return nil
 }
+
+// Uninstall removes all istio components istioctl installed and the istio-system namespace
+func (o *istioOpt) Uninstall() error {
+	istioUninstallCmd := "PATH=/opt/istio-" + istioVersion + "/bin:$PATH istioctl --kubeconfig /etc/kubernetes/admin.conf uninstall --purge -y"
+	if err := o.sshClient.Command(istioUninstallCmd); err != nil {
+		return err
+	}
+
+	bundle, err := common.ParseBundle(ns)
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *istioOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "istiod", "istio-system", k8s.DeploymentRolledOut)
+}
+
+// privilegeCNIDaemonSet waits for istioctl to create the CNI DaemonSet and makes its container privileged,
+// failed updates, e.g. because of conflicts with the operator, are retried until the context is done
+func (o *istioOpt) privilegeCNIDaemonSet(ctx context.Context) error {
+	daemonSetGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}
+	return k8s.WaitFor(ctx, o.client, daemonSetGVK, "istio-cni-node", "kube-system", func(obj *unstructured.Unstructured) (bool, string, error) {
+		cniDaemonSet := &appsv1.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, cniDaemonSet); err != nil {
+			return false, "", fmt.Errorf("error converting the CNI DaemonSet: %w", err)
+		}
+		if len(cniDaemonSet.Spec.Template.Spec.Containers) == 0 {
+			return false, "CNI DaemonSet has no containers yet", nil
+		}
+
+		container := &cniDaemonSet.Spec.Template.Spec.Containers[0]
+		if container.SecurityContext == nil {
+			container.SecurityContext = &corev1.SecurityContext{}
+		}
+		privileged := true
+		container.SecurityContext.Privileged = &privileged
+
+		newCniDaemonSet, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cniDaemonSet)
+		if err != nil {
+			return false, "", fmt.Errorf("error converting the CNI DaemonSet: %w", err)
+		}
+		if err := o.client.Update(&unstructured.Unstructured{Object: newCniDaemonSet}); err != nil {
+			return false, fmt.Sprintf("error patching the CNI DaemonSet: %v", err), nil
+		}
+		return true, "", nil
+	})
+}
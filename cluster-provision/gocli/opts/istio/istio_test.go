@@ -6,6 +6,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
@@ -29,6 +31,7 @@ var _ = Describe("IstioOpt", func() {
 		k8sclient = k8s.NewTestClient()
 		opt = NewIstioOpt(sshClient, k8sclient, false)
 		AddExpectCalls(sshClient)
+		Expect(CreateCNIDaemonSet(k8sclient)).To(Succeed())
 	})
 
 	AfterEach(func() {
@@ -38,5 +41,11 @@ var _ = Describe("IstioOpt", func() {
 	It("should execute IstioOpt successfully", func() {
 		err := opt.Exec()
 		Expect(err).NotTo(HaveOccurred())
+
+		obj, err := k8sclient.Get(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}, "istio-cni-node", "kube-system")
+		Expect(err).NotTo(HaveOccurred())
+		containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers[0]).To(HaveKeyWithValue("securityContext", HaveKeyWithValue("privileged", true)))
 	})
 })
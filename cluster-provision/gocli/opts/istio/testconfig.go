@@ -4,6 +4,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stesting "k8s.io/client-go/testing"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
@@ -19,6 +20,32 @@ var IstioReactor = func(action k8stesting.Action) (bool, runtime.Object, error)
 	return false, obj, nil
 }
 
+// CreateCNIDaemonSet creates the DaemonSet istioctl would deploy, so the opt is able to patch it
+func CreateCNIDaemonSet(client k8s.K8sDynamicClient) error {
+	obj, err := k8s.SerializeIntoObject([]byte(`apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: istio-cni-node
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      k8s-app: istio-cni-node
+  template:
+    metadata:
+      labels:
+        k8s-app: istio-cni-node
+    spec:
+      containers:
+      - name: install-cni
+        image: docker.io/istio/install-cni
+`))
+	if err != nil {
+		return err
+	}
+	return client.Apply(obj)
+}
+
 func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient) {
 	cmds := []string{
 		"source /var/lib/kubevirtci/shared_vars.sh",
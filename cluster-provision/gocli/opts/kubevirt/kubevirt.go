@@ -0,0 +1,236 @@
+package kubevirt
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed manifests/kubevirt-cr.yaml
+var cr []byte
+
+const (
+	namespace = "kubevirt"
+	// availableTimeout is how long to wait for KubeVirt to deploy all of its components
+	availableTimeout = 10 * time.Minute
+)
+
+var (
+	// releaseURL is the operator manifest of a KubeVirt release, the release replaces %s
+	releaseURL = "https://github.com/kubevirt/kubevirt/releases/download/%s/kubevirt-operator.yaml"
+	// stableURL holds the latest stable release of KubeVirt
+	stableURL = "https://storage.googleapis.com/kubevirt-prow/release/kubevirt/kubevirt/stable.txt"
+)
+
+var kubevirtGVK = schema.GroupVersionKind{Group: "kubevirt.io", Version: "v1", Kind: "KubeVirt"}
+
+type kubevirtOpt struct {
+	client        k8s.K8sDynamicClient
+	sshClient     libssh.Client
+	version       string
+	manifestsDir  string
+	featureGates  []string
+	configuration string
+	autoEmulation bool
+}
+
+// NewKubevirtOpt creates an opt which deploys the KubeVirt operator and CR. The operator is taken from manifestsDir if
+// it is set, or from the KubeVirt release of the version otherwise, the latest stable release if the version is empty.
+// featureGates are enabled and configuration is YAML merged into spec.configuration of the CR. With autoEmulation,
+// software emulation is enabled if the nodes lack nested virtualization
+func NewKubevirtOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, version, manifestsDir string, featureGates []string, configuration string, autoEmulation bool) *kubevirtOpt {
+	return &kubevirtOpt{
+		client:        c,
+		sshClient:     sshClient,
+		version:       version,
+		manifestsDir:  manifestsDir,
+		featureGates:  featureGates,
+		configuration: configuration,
+		autoEmulation: autoEmulation,
+	}
+}
+
+func (o *kubevirtOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "kubevirt"}
+}
+
+func (o *kubevirtOpt) Exec() error {
+	operator, err := o.operator()
+	if err != nil {
+		return err
+	}
+	kv, err := o.cr()
+	if err != nil {
+		return err
+	}
+
+	if o.autoEmulation {
+		// all nodes run on the same host with the same cpu model, so node01 tells whether nested virtualization is available
+		out, err := o.sshClient.CommandWithNoStdOut("test -c /dev/kvm && echo present || echo missing")
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(out) == "missing" {
+			logrus.Warn("/dev/kvm is missing on the nodes, enabling software emulation in KubeVirt")
+			if err := kv.Mutate(withEmulation); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := operator.Apply(o.client); err != nil {
+		return err
+	}
+	if err := kv.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), availableTimeout)
+	defer cancel()
+	if err := k8s.WaitForDeploymentRollout(ctx, o.client, "virt-operator", namespace); err != nil {
+		return err
+	}
+	// the operator reports the KubeVirt CR as available once virt-api, virt-controller and virt-handler are up
+	if err := k8s.WaitForCondition(ctx, o.client, kubevirtGVK, "kubevirt", namespace, "Available"); err != nil {
+		return err
+	}
+	logrus.Info("KubeVirt is ready!")
+	return nil
+}
+
+// Uninstall deletes the CR first and waits for the operator to remove all KubeVirt components, then the operator
+func (o *kubevirtOpt) Uninstall() error {
+	kv, err := o.cr()
+	if err != nil {
+		return err
+	}
+	if err := kv.Delete(o.client); err != nil {
+		return err
+	}
+
+	operator, err := o.operator()
+	if err != nil {
+		return err
+	}
+	return operator.Delete(o.client)
+}
+
+func (o *kubevirtOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, kubevirtGVK, "kubevirt", namespace, k8s.ConditionTrue("Available"))
+}
+
+// operator loads the operator manifests without any KubeVirt CR they might contain, the CR is created by the opt
+func (o *kubevirtOpt) operator() (*common.Bundle, error) {
+	if o.manifestsDir != "" {
+		bundle, err := common.LoadBundle(os.DirFS(o.manifestsDir), ".")
+		if err != nil {
+			return nil, fmt.Errorf("error loading the KubeVirt manifests of %s: %w", o.manifestsDir, err)
+		}
+		return bundle.Without(kubevirtGVK.Kind), nil
+	}
+
+	version := o.version
+	if version == "" {
+		stable, err := download(stableURL)
+		if err != nil {
+			return nil, fmt.Errorf("error getting the latest stable KubeVirt release: %w", err)
+		}
+		version = strings.TrimSpace(string(stable))
+	}
+	logrus.Infof("Deploying KubeVirt %s", version)
+	manifest, err := download(fmt.Sprintf(releaseURL, version))
+	if err != nil {
+		return nil, fmt.Errorf("error downloading KubeVirt %s: %w", version, err)
+	}
+	bundle, err := common.ParseBundle(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Without(kubevirtGVK.Kind), nil
+}
+
+func (o *kubevirtOpt) cr() (*common.Bundle, error) {
+	configuration, err := ParseConfiguration(o.configuration)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := common.ParseBundle(cr)
+	if err != nil {
+		return nil, err
+	}
+	if err := bundle.Mutate(withConfiguration(configuration, o.featureGates)); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// ParseConfiguration parses configuration YAML of the KubeVirt CR, so it can be validated before a cluster is created
+func ParseConfiguration(configuration string) (map[string]interface{}, error) {
+	parsed := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(configuration), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid KubeVirt configuration: %w", err)
+	}
+	return parsed, nil
+}
+
+// withConfiguration merges the top level fields of configuration into spec.configuration and adds the feature gates
+func withConfiguration(configuration map[string]interface{}, featureGates []string) common.Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		current, _, err := unstructured.NestedMap(obj.Object, "spec", "configuration")
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			current = map[string]interface{}{}
+		}
+		for field, value := range configuration {
+			current[field] = value
+		}
+
+		gates, _, err := unstructured.NestedStringSlice(current, "developerConfiguration", "featureGates")
+		if err != nil {
+			return err
+		}
+		for _, gate := range featureGates {
+			if !slices.Contains(gates, gate) {
+				gates = append(gates, gate)
+			}
+		}
+		if err := unstructured.SetNestedStringSlice(current, gates, "developerConfiguration", "featureGates"); err != nil {
+			return err
+		}
+		return unstructured.SetNestedMap(obj.Object, current, "spec", "configuration")
+	}
+}
+
+func withEmulation(obj *unstructured.Unstructured) error {
+	return unstructured.SetNestedField(obj.Object, true, "spec", "configuration", "developerConfiguration", "useEmulation")
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting %s failed, status code: %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,148 @@
+package kubevirt
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func TestKubevirtOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KubevirtOpt Suite")
+}
+
+const operatorManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: kubevirt
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: virt-operator
+  namespace: kubevirt
+spec:
+  template:
+    spec:
+      containers:
+      - name: virt-operator
+        image: quay.io/kubevirt/virt-operator:%s
+`
+
+var _ = Describe("KubevirtOpt", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		client    k8s.K8sDynamicClient
+		sshClient *kubevirtcimocks.MockSSHClient
+		server    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		client = k8s.NewTestClient(k8s.NewReactorConfig("create", "kubevirts", k8s.ConditionReactor("Available")))
+		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/stable.txt", func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintln(w, "v1.6.0")
+		})
+		mux.HandleFunc("/download/{version}/kubevirt-operator.yaml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, operatorManifest, r.PathValue("version"))
+		})
+		server = httptest.NewServer(mux)
+
+		originalReleaseURL, originalStableURL := releaseURL, stableURL
+		releaseURL, stableURL = server.URL+"/download/%s/kubevirt-operator.yaml", server.URL+"/stable.txt"
+		DeferCleanup(func() {
+			releaseURL, stableURL = originalReleaseURL, originalStableURL
+			server.Close()
+		})
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	operatorImage := func() string {
+		operator, err := client.Get(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "virt-operator", "kubevirt")
+		Expect(err).NotTo(HaveOccurred())
+		containers, _, err := unstructured.NestedSlice(operator.Object, "spec", "template", "spec", "containers")
+		Expect(err).NotTo(HaveOccurred())
+		return containers[0].(map[string]interface{})["image"].(string)
+	}
+
+	getCR := func() *unstructured.Unstructured {
+		kv, err := client.Get(kubevirtGVK, "kubevirt", "kubevirt")
+		Expect(err).NotTo(HaveOccurred())
+		return kv
+	}
+
+	It("should deploy the latest stable release by default", func() {
+		opt := NewKubevirtOpt(client, sshClient, "", "", nil, "", false)
+		Expect(opt.Exec()).To(Succeed())
+		Expect(operatorImage()).To(Equal("quay.io/kubevirt/virt-operator:v1.6.0"))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+	})
+
+	It("should deploy the given release with feature gates and configuration", func() {
+		opt := NewKubevirtOpt(client, sshClient, "v1.5.2", "", []string{"Snapshot", "HotplugVolumes"}, "vmRolloutStrategy: LiveUpdate\n", false)
+		Expect(opt.Exec()).To(Succeed())
+		Expect(operatorImage()).To(Equal("quay.io/kubevirt/virt-operator:v1.5.2"))
+
+		configuration, _, err := unstructured.NestedMap(getCR().Object, "spec", "configuration")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configuration).To(HaveKeyWithValue("vmRolloutStrategy", "LiveUpdate"))
+		Expect(configuration["developerConfiguration"]).To(Equal(map[string]interface{}{
+			"featureGates": []interface{}{"Snapshot", "HotplugVolumes"},
+		}))
+	})
+
+	It("should deploy the operator of a manifests directory and remove it again", func() {
+		dir := GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(dir, "kubevirt-operator.yaml"), []byte(fmt.Sprintf(operatorManifest, "devel")), 0644)).To(Succeed())
+
+		opt := NewKubevirtOpt(client, sshClient, "", dir, nil, "", false)
+		Expect(opt.Exec()).To(Succeed())
+		Expect(operatorImage()).To(Equal("quay.io/kubevirt/virt-operator:devel"))
+
+		Expect(opt.Uninstall()).To(Succeed())
+		_, err := client.Get(kubevirtGVK, "kubevirt", "kubevirt")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+
+	DescribeTable("should enable emulation only if the nodes lack nested virtualization", func(kvm string, expected bool) {
+		sshClient.EXPECT().CommandWithNoStdOut("test -c /dev/kvm && echo present || echo missing").Return(kvm+"\n", nil)
+
+		opt := NewKubevirtOpt(client, sshClient, "", "", nil, "", true)
+		Expect(opt.Exec()).To(Succeed())
+		useEmulation, _, err := unstructured.NestedBool(getCR().Object, "spec", "configuration", "developerConfiguration", "useEmulation")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(useEmulation).To(Equal(expected))
+	},
+		Entry("nested virtualization", "present", false),
+		Entry("no nested virtualization", "missing", true),
+	)
+
+	It("should refuse invalid configuration", func() {
+		_, err := ParseConfiguration("- not a map")
+		Expect(err).To(MatchError(ContainSubstring("invalid KubeVirt configuration")))
+	})
+})
@@ -0,0 +1,177 @@
+package kwok
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	"sigs.k8s.io/yaml"
+)
+
+//go:embed manifests/node.yaml
+var defaultNodeTemplate []byte
+
+const (
+	namespace = "kube-system"
+	// fakeNodeAnnotation marks the nodes the kwok-controller manages
+	fakeNodeAnnotation = "kwok.x-k8s.io/node"
+	// manifestsCmd prints the kwok manifests the provider ships in /opt/kwok, including the stages of KubeVirt VMIs
+	manifestsCmd = "for f in $(find /opt/kwok -name '*.yaml' ! -name kustomization.yaml | sort); do cat $f; echo; echo ---; done"
+	// nodesTimeout is how long to wait for the kwok-controller to report all fake nodes as ready
+	nodesTimeout = 5 * time.Minute
+)
+
+// DefaultNodes is the number of fake nodes registered when kwok is enabled on a running cluster
+const DefaultNodes = 1
+
+var nodeGVK = schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+
+type kwokOpt struct {
+	client       k8s.K8sDynamicClient
+	sshClient    libssh.Client
+	nodes        int
+	nodeTemplate []byte
+}
+
+// NewKwokOpt creates an opt which deploys the kwok-controller and registers the given number of fake nodes. The nodes
+// are created from nodeTemplate, a Node manifest which sets their capacity, labels and taints, or from a default
+// template if it is empty
+func NewKwokOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, nodes int, nodeTemplate []byte) *kwokOpt {
+	return &kwokOpt{
+		client:       c,
+		sshClient:    sshClient,
+		nodes:        nodes,
+		nodeTemplate: nodeTemplate,
+	}
+}
+
+func (o *kwokOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "kwok"}
+}
+
+func (o *kwokOpt) Exec() error {
+	template, err := ParseNodeTemplate(o.nodeTemplate)
+	if err != nil {
+		return err
+	}
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	if err := k8s.WaitForDeploymentRollout(ctx, o.client, "kwok-controller", namespace); err != nil {
+		return err
+	}
+
+	for i := 0; i < o.nodes; i++ {
+		if err := o.client.Apply(fakeNode(template, i)); err != nil {
+			return err
+		}
+	}
+
+	nodesCtx, nodesCancel := context.WithTimeout(context.Background(), nodesTimeout)
+	defer nodesCancel()
+	for i := 0; i < o.nodes; i++ {
+		if err := k8s.WaitForCondition(nodesCtx, o.client, nodeGVK, fakeNode(template, i).GetName(), "", "Ready"); err != nil {
+			return err
+		}
+	}
+	logrus.Infof("%d fake nodes are ready", o.nodes)
+	return nil
+}
+
+// Uninstall deletes all fake nodes, including the ones which were registered by other means than the opt
+func (o *kwokOpt) Uninstall() error {
+	nodes, err := o.client.List(nodeGVK, "")
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes.Items {
+		if !IsFakeNode(&node) {
+			continue
+		}
+		if err := o.client.Delete(nodeGVK, node.GetName(), ""); err != nil {
+			return err
+		}
+	}
+
+	bundle, err := o.bundle()
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *kwokOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "kwok-controller", namespace, k8s.DeploymentRolledOut)
+}
+
+func (o *kwokOpt) bundle() (*common.Bundle, error) {
+	manifests, err := o.sshClient.CommandWithNoStdOut(manifestsCmd)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the kwok manifests of the provider: %w", err)
+	}
+	return common.ParseBundle([]byte(manifests))
+}
+
+// IsFakeNode reports whether the node is one of the fake nodes the kwok-controller manages
+func IsFakeNode(node *unstructured.Unstructured) bool {
+	return node.GetAnnotations()[fakeNodeAnnotation] == "fake"
+}
+
+// ParseNodeTemplate parses a Node manifest the fake nodes are created from, so it can be validated before a cluster
+// is created. The default template is returned if it is empty
+func ParseNodeTemplate(nodeTemplate []byte) (*unstructured.Unstructured, error) {
+	if len(nodeTemplate) == 0 {
+		nodeTemplate = defaultNodeTemplate
+	}
+	template := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(nodeTemplate, &template.Object); err != nil {
+		return nil, fmt.Errorf("invalid kwok node template: %w", err)
+	}
+	if template.GroupVersionKind() != nodeGVK {
+		return nil, fmt.Errorf("invalid kwok node template: expected a v1 Node, got %s", template.GroupVersionKind())
+	}
+	return template, nil
+}
+
+// fakeNode creates the node with the given index from the template, it is named after the template, or kwok-node if
+// the template has no name
+func fakeNode(template *unstructured.Unstructured, index int) *unstructured.Unstructured {
+	node := template.DeepCopy()
+	prefix := node.GetName()
+	if prefix == "" {
+		prefix = "kwok-node"
+	}
+	name := fmt.Sprintf("%s-%d", prefix, index)
+	node.SetName(name)
+
+	labels := node.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels["kubernetes.io/hostname"] = name
+	node.SetLabels(labels)
+
+	annotations := node.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[fakeNodeAnnotation] = "fake"
+	node.SetAnnotations(annotations)
+	return node
+}
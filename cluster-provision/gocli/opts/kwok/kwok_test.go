@@ -0,0 +1,108 @@
+package kwok
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func TestKwokOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KwokOpt Suite")
+}
+
+const providerManifests = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kwok-controller
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: kwok-controller
+  namespace: kube-system
+---
+`
+
+const nodeTemplate = `apiVersion: v1
+kind: Node
+metadata:
+  name: big
+  labels:
+    type: kwok
+spec:
+  taints:
+  - effect: NoSchedule
+    key: scale
+status:
+  capacity:
+    cpu: "128"
+`
+
+var _ = Describe("KwokOpt", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		client    k8s.K8sDynamicClient
+		sshClient *kubevirtcimocks.MockSSHClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		client = k8s.NewTestClient(k8s.NewReactorConfig("create", "nodes", k8s.ConditionReactor("Ready")))
+		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		sshClient.EXPECT().CommandWithNoStdOut(manifestsCmd).Return(providerManifests, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should register fake nodes from the default template", func() {
+		opt := NewKwokOpt(client, sshClient, 3, nil)
+		Expect(opt.Exec()).To(Succeed())
+
+		for _, name := range []string{"kwok-node-0", "kwok-node-1", "kwok-node-2"} {
+			node, err := client.Get(nodeGVK, name, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.GetAnnotations()).To(HaveKeyWithValue(fakeNodeAnnotation, "fake"))
+			Expect(node.GetLabels()).To(HaveKeyWithValue("kubernetes.io/hostname", name))
+		}
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+	})
+
+	It("should register fake nodes from a custom template", func() {
+		opt := NewKwokOpt(client, sshClient, 1, []byte(nodeTemplate))
+		Expect(opt.Exec()).To(Succeed())
+
+		node, err := client.Get(nodeGVK, "big-0", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(node.GetLabels()).To(HaveKeyWithValue("type", "kwok"))
+		Expect(node.GetAnnotations()).To(HaveKeyWithValue(fakeNodeAnnotation, "fake"))
+	})
+
+	It("should remove the fake nodes on uninstall", func() {
+		opt := NewKwokOpt(client, sshClient, 2, nil)
+		Expect(opt.Exec()).To(Succeed())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		nodes, err := client.List(nodeGVK, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes.Items).To(BeEmpty())
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+
+	It("should reject a template of another kind", func() {
+		_, err := ParseNodeTemplate([]byte("apiVersion: v1\nkind: Pod\n"))
+		Expect(err).To(MatchError(ContainSubstring("expected a v1 Node")))
+	})
+})
@@ -0,0 +1,314 @@
+package localaddon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestFile is the file at the root of an add-on directory which describes the add-on
+const ManifestFile = "addon.yaml"
+
+const defaultTimeout = 5 * time.Minute
+
+const (
+	// recordNamespace and recordLabel identify the ConfigMaps recording the installed add-ons, so the add-ons
+	// depending on others are known when those are disabled, without the add-on directories
+	recordNamespace = "kube-system"
+	recordLabel     = "kubevirtci.io/local-addon"
+	recordKey       = ManifestFile
+)
+
+var configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+// Manifest describes an add-on which is deployed from a local directory
+type Manifest struct {
+	// Name identifies the add-on in dependencies and conflicts of other add-ons
+	Name         string   `json:"name"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	Conflicts    []string `json:"conflicts,omitempty"`
+	// KubernetesVersion is a semver constraint like ">=1.30", the add-on is skipped on clusters not satisfying it
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// Manifests are files or directories relative to the add-on directory, all of them are applied as one bundle
+	Manifests []string `json:"manifests,omitempty"`
+	// NodeScripts are files relative to the add-on directory which are run on every node before the manifests are applied
+	NodeScripts []string `json:"nodeScripts,omitempty"`
+	// ReadinessChecks have to pass before the add-on counts as ready
+	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+	// Timeout is how long to wait for the readiness checks, defaults to 5m
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ReadinessCheck waits for an object the add-on deploys or makes its operator create.
+// Without a condition or phase, deployments, daemonsets and statefulsets have to be rolled out and
+// all other objects have to exist
+type ReadinessCheck struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Condition is the type of a condition in status.conditions which has to be True
+	Condition string `json:"condition,omitempty"`
+	// Phase is the expected value of status.phase
+	Phase string `json:"phase,omitempty"`
+}
+
+// Addon is a loaded add-on directory
+type Addon struct {
+	Manifest
+	dir     string
+	bundle  *common.Bundle
+	scripts map[string][]byte
+}
+
+// Load reads and validates the add-on in dir, so mistakes show up before a cluster is created
+func Load(dir string) (*Addon, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading add-on %s: %w", dir, err)
+	}
+
+	a := &Addon{dir: dir, scripts: map[string][]byte{}}
+	if err := yaml.UnmarshalStrict(data, &a.Manifest); err != nil {
+		return nil, fmt.Errorf("error parsing %s of add-on %s: %w", ManifestFile, dir, err)
+	}
+	if err := a.validate(); err != nil {
+		return nil, fmt.Errorf("invalid add-on %s: %w", dir, err)
+	}
+
+	fsys := os.DirFS(dir)
+	a.bundle, err = common.LoadBundle(fsys, a.Manifests...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading manifests of add-on %s: %w", a.Name, err)
+	}
+	for _, script := range a.NodeScripts {
+		if a.scripts[script], err = os.ReadFile(filepath.Join(dir, script)); err != nil {
+			return nil, fmt.Errorf("error loading node script of add-on %s: %w", a.Name, err)
+		}
+	}
+	return a, nil
+}
+
+func (a *Addon) validate() error {
+	if errs := validation.IsDNS1123Label(a.Name); len(errs) > 0 {
+		return fmt.Errorf("name %q is not valid: %v", a.Name, errs)
+	}
+	if len(a.Manifests) == 0 && len(a.NodeScripts) == 0 {
+		return fmt.Errorf("neither manifests nor node scripts are given")
+	}
+	if a.KubernetesVersion != "" {
+		if _, err := semver.NewConstraint(a.KubernetesVersion); err != nil {
+			return fmt.Errorf("kubernetesVersion %q is not a valid constraint: %w", a.KubernetesVersion, err)
+		}
+	}
+	for _, p := range append(append([]string{}, a.Manifests...), a.NodeScripts...) {
+		// paths must stay inside of the add-on directory
+		if !filepath.IsLocal(p) || path.Clean(filepath.ToSlash(p)) != filepath.ToSlash(p) {
+			return fmt.Errorf("path %q has to be a clean path relative to the add-on directory", p)
+		}
+	}
+	for i, check := range a.ReadinessChecks {
+		if check.APIVersion == "" || check.Kind == "" || check.Name == "" {
+			return fmt.Errorf("readiness check %d needs an apiVersion, kind and name", i)
+		}
+		if check.Condition != "" && check.Phase != "" {
+			return fmt.Errorf("readiness check %d can either wait for a condition or a phase", i)
+		}
+	}
+	return nil
+}
+
+type localAddonOpt struct {
+	addon  *Addon
+	client k8s.K8sDynamicClient
+	nodes  []libssh.Client
+}
+
+// NewLocalAddonOpt creates an opt which deploys the add-on, nodes are the ssh clients of all nodes the node scripts run on
+func NewLocalAddonOpt(c k8s.K8sDynamicClient, nodes []libssh.Client, addon *Addon) *localAddonOpt {
+	return &localAddonOpt{
+		addon:  addon,
+		client: c,
+		nodes:  nodes,
+	}
+}
+
+func (o *localAddonOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{
+		Name:              o.addon.Name,
+		Dependencies:      o.addon.Dependencies,
+		Conflicts:         o.addon.Conflicts,
+		KubernetesVersion: o.addon.KubernetesVersion,
+	}
+}
+
+func (o *localAddonOpt) Exec() error {
+	for _, script := range o.addon.NodeScripts {
+		for i, node := range o.nodes {
+			logrus.Infof("Running %s of add-on %s on node %d", script, o.addon.Name, i+1)
+			if err := node.Command(string(o.addon.scripts[script])); err != nil {
+				return fmt.Errorf("error running %s on node %d: %w", script, i+1, err)
+			}
+		}
+	}
+
+	if err := o.addon.bundle.Apply(o.client); err != nil {
+		return err
+	}
+	record, err := o.record()
+	if err != nil {
+		return err
+	}
+	if err := o.client.Apply(record); err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+	if o.addon.Timeout != nil {
+		timeout = o.addon.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, check := range o.addon.ReadinessChecks {
+		if err := k8s.WaitFor(ctx, o.client, check.gvk(), check.Name, check.Namespace, check.readyFunc()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Uninstall deletes the manifests of the add-on and its record, changes the node scripts made to the nodes stay
+func (o *localAddonOpt) Uninstall() error {
+	if err := o.addon.bundle.Delete(o.client); err != nil {
+		return err
+	}
+	if err := o.client.Delete(configMapGVK, recordName(o.addon.Name), recordNamespace); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Status reports the add-on as installed once the object of the first readiness check exists, and as ready once
+// all readiness checks pass. Add-ons without readiness checks count as installed once their first object exists
+func (o *localAddonOpt) Status() (opts.Status, error) {
+	checks := o.addon.ReadinessChecks
+	if len(checks) == 0 {
+		objects := o.addon.bundle.Objects()
+		if len(objects) == 0 {
+			// there is nothing on the cluster which tells whether the node scripts ran
+			return opts.Status{}, nil
+		}
+		first := objects[0]
+		checks = []ReadinessCheck{{APIVersion: first.GetAPIVersion(), Kind: first.GetKind(), Name: first.GetName(), Namespace: first.GetNamespace()}}
+	}
+
+	status := opts.Status{}
+	for i, check := range checks {
+		checkStatus, err := common.CheckStatus(o.client, check.gvk(), check.Name, check.Namespace, check.readyFunc())
+		if err != nil {
+			return opts.Status{}, err
+		}
+		if i == 0 {
+			if !checkStatus.Installed {
+				return opts.Status{}, nil
+			}
+			status = opts.Status{Installed: true, Ready: true}
+		}
+		if !checkStatus.Ready {
+			status.Ready = false
+			status.Message = fmt.Sprintf("%s %s: %s", check.Kind, check.Name, checkStatus.Message)
+			if !checkStatus.Installed {
+				status.Message = fmt.Sprintf("%s %s does not exist", check.Kind, check.Name)
+			}
+			break
+		}
+	}
+	return status, nil
+}
+
+// record is the ConfigMap which records that the add-on is installed, it holds the manifest of the add-on
+func (o *localAddonOpt) record() (*unstructured.Unstructured, error) {
+	manifest, err := yaml.Marshal(o.addon.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	record := &unstructured.Unstructured{}
+	record.SetAPIVersion("v1")
+	record.SetKind("ConfigMap")
+	record.SetName(recordName(o.addon.Name))
+	record.SetNamespace(recordNamespace)
+	record.SetLabels(map[string]string{recordLabel: o.addon.Name})
+	if err := unstructured.SetNestedStringMap(record.Object, map[string]string{recordKey: string(manifest)}, "data"); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func recordName(name string) string {
+	return "kubevirtci-addon-" + name
+}
+
+// Installed returns the manifests of the add-ons which were deployed from directories and are still installed
+func Installed(c k8s.K8sDynamicClient) ([]Manifest, error) {
+	configMaps, err := c.List(configMapGVK, recordNamespace)
+	if err != nil {
+		return nil, err
+	}
+	manifests := []Manifest{}
+	for _, configMap := range configMaps.Items {
+		if _, exists := configMap.GetLabels()[recordLabel]; !exists {
+			continue
+		}
+		data, _, err := unstructured.NestedStringMap(configMap.Object, "data")
+		if err != nil {
+			return nil, err
+		}
+		manifest := Manifest{}
+		if err := yaml.Unmarshal([]byte(data[recordKey]), &manifest); err != nil {
+			return nil, fmt.Errorf("error parsing the record %s of an add-on: %w", configMap.GetName(), err)
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+func (c ReadinessCheck) gvk() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(c.APIVersion, c.Kind)
+}
+
+func (c ReadinessCheck) readyFunc() k8s.ReadyFunc {
+	switch {
+	case c.Condition != "":
+		return k8s.ConditionTrue(c.Condition)
+	case c.Phase != "":
+		return k8s.PhaseIs(c.Phase)
+	}
+
+	switch c.gvk().GroupKind() {
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		return k8s.DeploymentRolledOut
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		return k8s.DaemonSetRolledOut
+	case schema.GroupKind{Group: "apps", Kind: "StatefulSet"}:
+		return k8s.StatefulSetRolledOut
+	}
+	return func(*unstructured.Unstructured) (bool, string, error) {
+		return true, "", nil
+	}
+}
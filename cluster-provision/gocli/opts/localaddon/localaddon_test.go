@@ -0,0 +1,143 @@
+package localaddon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func TestLocalAddon(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LocalAddon Suite")
+}
+
+const operator = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: widgets
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: widget-operator
+  namespace: widgets
+spec:
+  template:
+    spec:
+      containers:
+      - name: operator
+        image: quay.io/example/widget-operator:v1.0.0
+`
+
+var _ = Describe("Local add-on", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		node01    *kubevirtcimocks.MockSSHClient
+		node02    *kubevirtcimocks.MockSSHClient
+		k8sClient k8s.K8sDynamicClient
+		dir       string
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		node01 = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		node02 = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		k8sClient = k8s.NewTestClient()
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "manifests"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "manifests", "operator.yaml"), []byte(operator), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "setup.sh"), []byte("modprobe widget"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	writeManifest := func(manifest string) {
+		Expect(os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644)).To(Succeed())
+	}
+
+	It("should run the node scripts, apply the manifests and wait for the readiness checks", func() {
+		writeManifest(`name: widgets
+dependencies: [multus]
+manifests: [manifests]
+nodeScripts: [setup.sh]
+readinessChecks:
+- apiVersion: apps/v1
+  kind: Deployment
+  name: widget-operator
+  namespace: widgets
+`)
+		addon, err := Load(dir)
+		Expect(err).NotTo(HaveOccurred())
+		opt := NewLocalAddonOpt(k8sClient, []libssh.Client{node01, node02}, addon)
+		Expect(opt.Descriptor().Dependencies).To(Equal([]string{"multus"}))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+
+		node01.EXPECT().Command("modprobe widget")
+		node02.EXPECT().Command("modprobe widget")
+		Expect(opt.Exec()).To(Succeed())
+
+		status, err = opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeTrue())
+		Expect(status.Ready).To(BeTrue())
+
+		installed, err := Installed(k8sClient)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installed).To(HaveLen(1))
+		Expect(installed[0].Name).To(Equal("widgets"))
+		Expect(installed[0].Dependencies).To(Equal([]string{"multus"}))
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err = opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+		Expect(Installed(k8sClient)).To(BeEmpty())
+	})
+
+	It("should fail once the readiness checks time out", func() {
+		writeManifest(`name: widgets
+manifests: [manifests/operator.yaml]
+timeout: 10ms
+readinessChecks:
+- apiVersion: v1
+  kind: Namespace
+  name: widgets
+  phase: Active
+`)
+		addon, err := Load(dir)
+		Expect(err).NotTo(HaveOccurred())
+		opt := NewLocalAddonOpt(k8sClient, nil, addon)
+
+		Expect(opt.Exec()).To(MatchError(ContainSubstring(`timed out waiting for Namespace widgets: phase is "", expected "Active"`)))
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeTrue())
+		Expect(status.Message).To(Equal(`Namespace widgets: phase is "", expected "Active"`))
+	})
+
+	DescribeTable("should refuse invalid add-ons", func(manifest, expected string) {
+		writeManifest(manifest)
+		_, err := Load(dir)
+		Expect(err).To(MatchError(ContainSubstring(expected)))
+	},
+		Entry("invalid name", "name: My_Addon\nmanifests: [manifests]\n", `name "My_Addon" is not valid`),
+		Entry("nothing to deploy", "name: widgets\n", "neither manifests nor node scripts are given"),
+		Entry("path outside of the directory", "name: widgets\nmanifests: [../manifests]\n", `path "../manifests" has to be a clean path`),
+		Entry("invalid Kubernetes version", "name: widgets\nmanifests: [manifests]\nkubernetesVersion: latest\n", `kubernetesVersion "latest" is not a valid constraint`),
+		Entry("unknown field", "name: widgets\nmanifest: [manifests]\n", `unknown field "manifest"`),
+		Entry("missing script", "name: widgets\nnodeScripts: [missing.sh]\n", "error loading node script of add-on widgets"),
+		Entry("incomplete readiness check", "name: widgets\nmanifests: [manifests]\nreadinessChecks:\n- kind: Deployment\n", "readiness check 0 needs an apiVersion, kind and name"),
+	)
+})
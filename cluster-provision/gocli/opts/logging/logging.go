@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"embed"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/*
+var f embed.FS
+
+const (
+	// LokiURL is the address Loki is reached on in the cluster, e.g. by the Grafana datasource
+	LokiURL = "http://loki.logging.svc:3100"
+	// NodePort publishes the API of Loki on node01
+	NodePort = 30010
+
+	namespace = "logging"
+)
+
+type loggingOpt struct {
+	client k8s.K8sDynamicClient
+}
+
+// NewLoggingOpt creates an opt which deploys Loki and promtail on every node, so the logs of all pods are kept
+// after the pods are gone
+func NewLoggingOpt(c k8s.K8sDynamicClient) *loggingOpt {
+	return &loggingOpt{
+		client: c,
+	}
+}
+
+func (o *loggingOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "logging"}
+}
+
+func (o *loggingOpt) Exec() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := k8s.WaitForStatefulSetRollout(ctx, o.client, "loki", namespace); err != nil {
+		return err
+	}
+	if err := k8s.WaitForDaemonSetRollout(ctx, o.client, "promtail", namespace); err != nil {
+		return err
+	}
+	logrus.Info("Loki is collecting the logs of all pods")
+	return nil
+}
+
+// Uninstall removes Loki and promtail, the logs stay in /var/lib/loki on node01
+func (o *loggingOpt) Uninstall() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *loggingOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("StatefulSet"), "loki", namespace, k8s.StatefulSetRolledOut)
+}
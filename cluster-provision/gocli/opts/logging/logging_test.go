@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestLoggingOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LoggingOpt Suite")
+}
+
+var _ = Describe("LoggingOpt", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	It("should deploy Loki and promtail", func() {
+		opt := NewLoggingOpt(client)
+		Expect(opt.Exec()).To(Succeed())
+
+		_, err := client.Get(appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "promtail", namespace)
+		Expect(err).NotTo(HaveOccurred())
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err = opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+})
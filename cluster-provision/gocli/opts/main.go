@@ -3,3 +3,24 @@ package opts
 type Opt interface {
 	Exec() error
 }
+
+// Status describes the state of an addon on a running cluster
+type Status struct {
+	Installed bool
+	Ready     bool
+	// Message explains why an installed addon is not ready
+	Message string
+}
+
+// Addon is an opt which can be enabled and disabled on a running cluster
+type Addon interface {
+	ScheduledOpt
+	// Uninstall removes everything Exec deployed, it succeeds if the addon is not installed
+	Uninstall() error
+	Status() (Status, error)
+}
+
+// Syncer is an addon which deploys content of a directory on the host, Sync deploys the current content again
+type Syncer interface {
+	Sync() error
+}
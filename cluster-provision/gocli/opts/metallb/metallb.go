@@ -0,0 +1,116 @@
+package metallb
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/metallb.yaml
+var metallb []byte
+
+//go:embed manifests/pool.yaml
+var pool []byte
+
+// DefaultAddressPool is the part of the node network above the range dnsmasq hands out addresses from
+const DefaultAddressPool = "192.168.66.201-192.168.66.250"
+
+const namespace = "metallb-system"
+
+type metallbOpt struct {
+	client      k8s.K8sDynamicClient
+	addressPool string
+	interfaces  []string
+}
+
+// NewMetallbOpt creates an opt which deploys MetalLB in L2 mode. addressPool is a range or CIDR the LoadBalancer IPs
+// are taken from, they are announced on the given node interfaces, or on all interfaces if none are given
+func NewMetallbOpt(c k8s.K8sDynamicClient, addressPool string, interfaces []string) *metallbOpt {
+	return &metallbOpt{
+		client:      c,
+		addressPool: addressPool,
+		interfaces:  interfaces,
+	}
+}
+
+func (o *metallbOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "metallb"}
+}
+
+func (o *metallbOpt) Exec() error {
+	bundle, err := common.ParseBundle(metallb)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	if err := k8s.WaitForDeploymentRollout(ctx, o.client, "controller", namespace); err != nil {
+		return err
+	}
+	if err := k8s.WaitForDaemonSetRollout(ctx, o.client, "speaker", namespace); err != nil {
+		return err
+	}
+
+	poolBundle, err := o.pool()
+	if err != nil {
+		return err
+	}
+	if err := poolBundle.Apply(o.client); err != nil {
+		return err
+	}
+	logrus.Infof("MetalLB assigns LoadBalancer IPs from %s", o.addressPool)
+	return nil
+}
+
+func (o *metallbOpt) Uninstall() error {
+	poolBundle, err := o.pool()
+	if err != nil {
+		return err
+	}
+	if err := poolBundle.Delete(o.client); err != nil {
+		return err
+	}
+
+	bundle, err := common.ParseBundle(metallb)
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *metallbOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "controller", namespace, k8s.DeploymentRolledOut)
+}
+
+func (o *metallbOpt) pool() (*common.Bundle, error) {
+	bundle, err := common.ParseBundle(pool)
+	if err != nil {
+		return nil, err
+	}
+	err = bundle.Mutate(func(obj *unstructured.Unstructured) error {
+		switch obj.GetKind() {
+		case "IPAddressPool":
+			return unstructured.SetNestedStringSlice(obj.Object, []string{o.addressPool}, "spec", "addresses")
+		case "L2Advertisement":
+			if len(o.interfaces) > 0 {
+				return unstructured.SetNestedStringSlice(obj.Object, o.interfaces, "spec", "interfaces")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
@@ -0,0 +1,55 @@
+package metallb
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestMetallbOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MetallbOpt Suite")
+}
+
+var _ = Describe("MetallbOpt", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	It("should deploy MetalLB with an address pool on the node network", func() {
+		opt := NewMetallbOpt(client, DefaultAddressPool, nil)
+		Expect(opt.Exec()).To(Succeed())
+
+		pool, err := client.Get(schema.GroupVersionKind{Group: "metallb.io", Version: "v1beta1", Kind: "IPAddressPool"}, "default", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		addresses, _, err := unstructured.NestedStringSlice(pool.Object, "spec", "addresses")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(addresses).To(Equal([]string{"192.168.66.201-192.168.66.250"}))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+	})
+
+	It("should announce the address pool on the given interfaces only", func() {
+		opt := NewMetallbOpt(client, "10.10.0.0/24", []string{"br1"})
+		Expect(opt.Exec()).To(Succeed())
+
+		advertisement, err := client.Get(schema.GroupVersionKind{Group: "metallb.io", Version: "v1beta1", Kind: "L2Advertisement"}, "default", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		interfaces, _, err := unstructured.NestedStringSlice(advertisement.Object, "spec", "interfaces")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(interfaces).To(Equal([]string{"br1"}))
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+})
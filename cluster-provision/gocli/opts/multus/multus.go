@@ -1,11 +1,13 @@
 package multus
 
 import (
-	"bytes"
+	"context"
 	_ "embed"
-	"fmt"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
@@ -13,36 +15,59 @@ import (
 //go:embed manifests/multus.yaml
 var multus []byte
 
+// removeConfig removes the CNI configuration multus generates on a node, which is left behind by its pods
+const removeConfig = "sudo rm -rf /etc/cni/net.d/00-multus.conf /etc/cni/net.d/multus.d"
+
 type multusOpt struct {
-	client    k8s.K8sDynamicClient
-	sshClient libssh.Client
+	client k8s.K8sDynamicClient
+	nodes  []libssh.Client
 }
 
-func NewMultusOpt(c k8s.K8sDynamicClient, sshClient libssh.Client) *multusOpt {
+// NewMultusOpt creates an opt which deploys multus, nodes are the ssh clients of all nodes
+func NewMultusOpt(c k8s.K8sDynamicClient, nodes []libssh.Client) *multusOpt {
 	return &multusOpt{
-		client:    c,
-		sshClient: sshClient,
+		client: c,
+		nodes:  nodes,
 	}
 }
 
+func (o *multusOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "multus"}
+}
+
 func (o *multusOpt) Exec() error {
-	yamlDocs := bytes.Split(multus, []byte("---\n"))
-	for _, yamlDoc := range yamlDocs {
-		if len(yamlDoc) == 0 {
-			continue
-		}
+	bundle, err := common.ParseBundle(multus)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
 
-		obj, err := k8s.SerializeIntoObject(yamlDoc)
-		if err != nil {
-			logrus.Info(err.Error())
-			continue
-		}
-		if err := o.client.Apply(obj); err != nil {
-			return fmt.Errorf("error applying manifest %s", err)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	return k8s.WaitForDaemonSetRollout(ctx, o.client, "kube-multus-ds", "kube-system")
+}
+
+// Uninstall deletes the DaemonSet and removes the multus CNI configuration from the nodes, otherwise the
+// container runtime keeps calling multus for new pods
+func (o *multusOpt) Uninstall() error {
+	bundle, err := common.ParseBundle(multus)
+	if err != nil {
+		return err
 	}
-	if err := o.sshClient.Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf rollout status -n kube-system ds/kube-multus-ds --timeout=200s"); err != nil {
+	if err := bundle.Delete(o.client); err != nil {
 		return err
 	}
+
+	for _, node := range o.nodes {
+		if err := node.Command(removeConfig); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+func (o *multusOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "kube-multus-ds", "kube-system", k8s.DaemonSetRolledOut)
+}
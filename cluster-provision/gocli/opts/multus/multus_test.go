@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
@@ -17,27 +18,34 @@ func TestMultusOpt(t *testing.T) {
 
 var _ = Describe("MultusOpt", func() {
 	var (
-		mockCtrl  *gomock.Controller
-		sshClient *kubevirtcimocks.MockSSHClient
 		k8sClient k8s.K8sDynamicClient
+		node01    *kubevirtcimocks.MockSSHClient
+		node02    *kubevirtcimocks.MockSSHClient
 		opt       *multusOpt
 	)
 
 	BeforeEach(func() {
-		mockCtrl = gomock.NewController(GinkgoT())
-		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
 		k8sClient = k8s.NewTestClient()
-		opt = NewMultusOpt(k8sClient, sshClient)
-
-		sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf rollout status -n kube-system ds/kube-multus-ds --timeout=200s")
-	})
-
-	AfterEach(func() {
-		mockCtrl.Finish()
+		mockCtrl := gomock.NewController(GinkgoT())
+		node01 = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		node02 = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		opt = NewMultusOpt(k8sClient, []libssh.Client{node01, node02})
 	})
 
 	It("should execute MultusOpt successfully", func() {
 		err := opt.Exec()
 		Expect(err).NotTo(HaveOccurred())
 	})
+
+	It("should remove the multus configuration from all nodes on uninstall", func() {
+		Expect(opt.Exec()).To(Succeed())
+
+		AddUninstallExpectCalls(node01)
+		AddUninstallExpectCalls(node02)
+		Expect(opt.Uninstall()).To(Succeed())
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
 })
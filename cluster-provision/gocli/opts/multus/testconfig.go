@@ -0,0 +1,9 @@
+package multus
+
+import (
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func AddUninstallExpectCalls(sshClient *kubevirtcimocks.MockSSHClient) {
+	sshClient.EXPECT().Command(removeConfig)
+}
@@ -1,11 +1,14 @@
 package network_resources_injector
 
 import (
+	"context"
 	_ "embed"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
-	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
 //go:embed manifests/auth.yaml
@@ -18,33 +21,45 @@ var service []byte
 var server []byte
 
 type networkResourcesInjectorOpt struct {
-	client    k8s.K8sDynamicClient
-	sshClient libssh.Client
+	client k8s.K8sDynamicClient
 }
 
-func NewNetworkResourcesInjectorOpt(sc libssh.Client, c k8s.K8sDynamicClient) *networkResourcesInjectorOpt {
+func NewNetworkResourcesInjectorOpt(c k8s.K8sDynamicClient) *networkResourcesInjectorOpt {
 	return &networkResourcesInjectorOpt{
-		client:    c,
-		sshClient: sc,
+		client: c,
 	}
 }
 
+func (o *networkResourcesInjectorOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "network-resources-injector"}
+}
+
 func (o *networkResourcesInjectorOpt) Exec() error {
-	if err := common.ApplyYAML(auth, o.client); err != nil {
-		return err
-	}
-	obj, err := k8s.SerializeIntoObject(service)
+	bundle, err := o.bundle()
 	if err != nil {
 		return err
 	}
-
-	if err = o.client.Apply(obj); err != nil {
+	if err := bundle.Apply(o.client); err != nil {
 		return err
 	}
 
-	if err = common.ApplyYAML(server, o.client); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	return k8s.WaitForDeploymentRollout(ctx, o.client, "network-resources-injector", "kube-system")
+}
+
+func (o *networkResourcesInjectorOpt) Uninstall() error {
+	bundle, err := o.bundle()
+	if err != nil {
 		return err
 	}
+	return bundle.Delete(o.client)
+}
+
+func (o *networkResourcesInjectorOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "network-resources-injector", "kube-system", k8s.DeploymentRolledOut)
+}
 
-	return o.sshClient.Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf rollout status -n kube-system deploy/network-resources-injector --timeout=200s")
+func (o *networkResourcesInjectorOpt) bundle() (*common.Bundle, error) {
+	return common.ParseBundle(auth, service, server)
 }
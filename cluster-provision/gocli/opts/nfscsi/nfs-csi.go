@@ -1,103 +1,68 @@
 package nfscsi
 
 import (
-	"bytes"
+	"context"
 	"embed"
 	"fmt"
-	"io/fs"
-	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	"github.com/sirupsen/logrus"
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
-//go:embed manifests/*
+// probeServerCmd prints open if node01 reaches the nfs server, nfs resolves to dnsmasq on every cluster but only
+// clusters which were started with an nfs server accept connections on its port
+const probeServerCmd = `timeout 5 bash -c 'echo > /dev/tcp/nfs/2049' 2>/dev/null && echo open || true`
+
+//go:embed manifests/* migration/*
 var f embed.FS
 
 type nfsCsiOpt struct {
-	client k8s.K8sDynamicClient
+	client       k8s.K8sDynamicClient
+	sshClient    libssh.Client
+	secondExport bool
 }
 
-func NewNfsCsiOpt(c k8s.K8sDynamicClient) *nfsCsiOpt {
+// NewNfsCsiOpt creates an opt which deploys the NFS CSI driver for the nfs server of the cluster, with secondExport
+// the nfs-csi-migration StorageClass provisions volumes on the second export of the server, /migration
+func NewNfsCsiOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, secondExport bool) *nfsCsiOpt {
 	return &nfsCsiOpt{
-		client: c,
+		client:       c,
+		sshClient:    sshClient,
+		secondExport: secondExport,
 	}
 }
 
-func (o *nfsCsiOpt) Exec() error {
-	err := fs.WalkDir(f, "manifests", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() && filepath.Ext(path) == ".yaml" {
-			yamlData, err := f.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			yamlDocs := bytes.Split(yamlData, []byte("---\n"))
-			for _, yamlDoc := range yamlDocs {
-				if len(yamlDoc) == 0 {
-					continue
-				}
+func (o *nfsCsiOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "nfs-csi"}
+}
 
-				obj, err := k8s.SerializeIntoObject(yamlDoc)
-				if err != nil {
-					logrus.Info(err.Error())
-					continue
-				}
-				if err := o.client.Apply(obj); err != nil {
-					return fmt.Errorf("error applying manifest %s", err)
-				}
-			}
-		}
-		return nil
-	})
+func (o *nfsCsiOpt) Exec() error {
+	if err := o.checkServer(); err != nil {
+		return err
+	}
 
+	paths := []string{"manifests"}
+	if o.secondExport {
+		paths = append(paths, "migration")
+	}
+	bundle, err := common.LoadBundle(f, paths...)
 	if err != nil {
 		return err
 	}
-
-	pvc := &corev1.PersistentVolumeClaim{}
-
-	operation := func() error {
-		obj, err := o.client.Get(schema.GroupVersionKind{
-			Group:   "",
-			Version: "v1",
-			Kind:    "PersistentVolumeClaim",
-		}, "pvc-nfs-dynamic", "nfs-csi")
-
-		if err != nil {
-			logrus.Errorf("Attempt failed, PVC is still not bound: %v", err)
-			return err
-		}
-
-		err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, pvc)
-		if err != nil {
-			logrus.Errorf("Attempt failed, PVC is still not bound: %v", err)
-			return err
-		}
-
-		if pvc.Status.Phase != "Bound" {
-			err := fmt.Errorf("PVC didn't move to Bound phase")
-			logrus.Info(err)
-			return err
-		}
-
-		return nil
+	if err := bundle.Apply(o.client); err != nil {
+		return err
 	}
 
-	backoffStrategy := backoff.NewExponentialBackOff()
-	backoffStrategy.InitialInterval = 10 * time.Second
-	backoffStrategy.MaxElapsedTime = 5 * time.Minute
-
-	err = backoff.Retry(operation, backoffStrategy)
-	if err != nil {
-		return fmt.Errorf("waiting on PVC to become bound failed after maximum retries: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := k8s.WaitForPVCBound(ctx, o.client, "pvc-nfs-dynamic", "nfs-csi"); err != nil {
+		return err
 	}
 
 	err = o.client.Delete(schema.GroupVersionKind{
@@ -112,3 +77,27 @@ func (o *nfsCsiOpt) Exec() error {
 
 	return nil
 }
+
+// checkServer fails if node01 can not reach the nfs server, the test PVC would never be bound then
+func (o *nfsCsiOpt) checkServer() error {
+	out, err := o.sshClient.CommandWithNoStdOut(probeServerCmd)
+	if err != nil {
+		return fmt.Errorf("error looking for the nfs server: %w", err)
+	}
+	if strings.TrimSpace(out) != "open" {
+		return fmt.Errorf("node01 can not reach an nfs server, nfs-csi can only be enabled on clusters which were started with --enable-nfs-csi")
+	}
+	return nil
+}
+
+func (o *nfsCsiOpt) Uninstall() error {
+	bundle, err := common.LoadBundle(f, "manifests", "migration")
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *nfsCsiOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, "csi-nfs-controller", "nfs-csi", k8s.DeploymentRolledOut)
+}
@@ -6,7 +6,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
 func TestNfsCsiOpt(t *testing.T) {
@@ -17,15 +20,17 @@ func TestNfsCsiOpt(t *testing.T) {
 var _ = Describe("NfsCsiOpt", func() {
 	var (
 		mockCtrl  *gomock.Controller
+		sshClient *kubevirtcimocks.MockSSHClient
 		k8sClient k8s.K8sDynamicClient
 		opt       *nfsCsiOpt
 	)
 
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
+		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
 		r := k8s.NewReactorConfig("create", "persistentvolumeclaims", NfsCsiReactor)
 		k8sClient = k8s.NewTestClient(r)
-		opt = NewNfsCsiOpt(k8sClient)
+		opt = NewNfsCsiOpt(k8sClient, sshClient, false)
 	})
 
 	AfterEach(func() {
@@ -33,7 +38,33 @@ var _ = Describe("NfsCsiOpt", func() {
 	})
 
 	It("should execute NfsCsiOpt successfully", func() {
+		AddExpectCalls(sshClient)
 		err := opt.Exec()
 		Expect(err).NotTo(HaveOccurred())
+
+		_, err = k8sClient.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "nfs-csi-migration", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should create the StorageClass of the second export", func() {
+		AddExpectCalls(sshClient)
+		opt = NewNfsCsiOpt(k8sClient, sshClient, true)
+		Expect(opt.Exec()).To(Succeed())
+
+		storageClass, err := k8sClient.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "nfs-csi-migration", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(storageClass.Object["parameters"]).To(HaveKeyWithValue("share", "/migration"))
+
+		Expect(opt.Uninstall()).To(Succeed())
+		_, err = k8sClient.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "nfs-csi-migration", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should refuse clusters without an nfs server", func() {
+		AddServerExpectCalls(sshClient, false)
+		Expect(opt.Exec()).To(MatchError(ContainSubstring("node01 can not reach an nfs server")))
+
+		_, err := k8sClient.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "nfs-csi", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
 	})
 })
@@ -4,6 +4,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stesting "k8s.io/client-go/testing"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
 var NfsCsiReactor = func(action k8stesting.Action) (bool, runtime.Object, error) {
@@ -17,3 +18,15 @@ var NfsCsiReactor = func(action k8stesting.Action) (bool, runtime.Object, error)
 	}
 	return false, obj, nil
 }
+
+func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient) {
+	AddServerExpectCalls(sshClient, true)
+}
+
+func AddServerExpectCalls(sshClient *kubevirtcimocks.MockSSHClient, reachable bool) {
+	out := "\n"
+	if reachable {
+		out = "open\n"
+	}
+	sshClient.EXPECT().CommandWithNoStdOut(`timeout 5 bash -c 'echo > /dev/tcp/nfs/2049' 2>/dev/null && echo open || true`).Return(out, nil)
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"fmt"
 
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cni"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
@@ -16,16 +17,17 @@ var setupBridgesScript []byte
 type node01Provisioner struct {
 	sshClient           libssh.Client
 	singleStack         bool
-	flannel             bool
+	cni                 *cni.CNI
 	etcdNoFsync         bool
 	secondaryNicBridges bool
 }
 
-func NewNode01Provisioner(sc libssh.Client, singleStack, flannel, etcdNoFsync, secondaryNicBridges bool) *node01Provisioner {
+// NewNode01Provisioner creates the opt initializing the cluster on node01 with the given CNI
+func NewNode01Provisioner(sc libssh.Client, singleStack bool, c *cni.CNI, etcdNoFsync, secondaryNicBridges bool) *node01Provisioner {
 	return &node01Provisioner{
 		sshClient:           sc,
 		singleStack:         singleStack,
-		flannel:             flannel,
+		cni:                 c,
 		etcdNoFsync:         etcdNoFsync,
 		secondaryNicBridges: secondaryNicBridges,
 	}
@@ -33,26 +35,14 @@ func NewNode01Provisioner(sc libssh.Client, singleStack, flannel, etcdNoFsync, s
 
 func (n *node01Provisioner) Exec() error {
 	var (
-		kubeadmConf = "/etc/kubernetes/kubeadm.conf"
-		cniManifest = "/provision/cni.yaml"
+		kubeadmConf = n.cni.KubeadmConfFor(n.singleStack)
+		cniManifest = n.cni.ManifestFor(n.singleStack)
 	)
 
-	if n.flannel {
-		kubeadmConf = "/etc/kubernetes/kubeadm_flannel.conf"
-		cniManifest = "/etc/kubernetes/flannel.yaml"
-	}
-
-	if n.singleStack {
-		if n.flannel {
-			kubeadmConf = "/etc/kubernetes/kubeadm_flannel_ipv6.conf"
-			cniManifest = "/etc/kubernetes/flannel_ipv6.yaml"
-		} else {
-			kubeadmConf = "/etc/kubernetes/kubeadm_ipv6.conf"
-			cniManifest = "/provision/cni_ipv6.yaml"
-		}
-	}
-
 	kubeadmInitCmd := "kubeadm init --config " + kubeadmConf + " -v5"
+	if n.cni.SkipKubeProxy {
+		kubeadmInitCmd += " --skip-phases=addon/kube-proxy"
+	}
 	if n.etcdNoFsync {
 		kubeadmInitCmd = fmt.Sprintf("sed -i 's/#etcdExtraArgs/extraArgs: \\{unsafe-no-fsync: \\\"True\\\"}/' %s && %s", kubeadmConf, kubeadmInitCmd)
 	}
@@ -73,6 +63,9 @@ func (n *node01Provisioner) Exec() error {
 		kubeadmInitCmd,
 		`kubectl --kubeconfig=/etc/kubernetes/admin.conf patch deployment coredns -n kube-system -p "$(cat /provision/kubeadm-patches/add-security-context-deployment-patch.yaml)"`,
 		`kubectl --kubeconfig=/etc/kubernetes/admin.conf create -f `+cniManifest,
+	)
+	cmds = append(cmds, n.cni.PostInstall...)
+	cmds = append(cmds,
 		`kubectl --kubeconfig=/etc/kubernetes/admin.conf taint nodes node01 node-role.kubernetes.io/control-plane:NoSchedule-`,
 		`kubectl --kubeconfig=/etc/kubernetes/admin.conf get nodes --no-headers; kubectl_rc=$?; retry_counter=0; while [[ $retry_counter -lt 20 && $kubectl_rc -ne 0 ]]; do sleep 10; echo "Waiting for api server to be available...";  kubectl --kubeconfig=/etc/kubernetes/admin.conf get nodes --no-headers; kubectl_rc=$?; retry_counter=$((retry_counter + 1)); done`,
 		"kubectl --kubeconfig=/etc/kubernetes/admin.conf version",
@@ -83,15 +76,7 @@ func (n *node01Provisioner) Exec() error {
 	for _, cmd := range cmds {
 		err := n.sshClient.Command(cmd)
 		if err != nil {
-			return fmt.Errorf("error executing %s: %s", cmd, err)
-		}
-	}
-
-	if n.flannel {
-		cmd := `kubectl --kubeconfig=/etc/kubernetes/admin.conf create -f /etc/kubernetes/knp.yaml`
-		err := n.sshClient.Command(cmd)
-		if err != nil {
-			return fmt.Errorf("error executing %s: %s", cmd, err)
+			return fmt.Errorf("error provisioning node: %w", err)
 		}
 	}
 	return nil
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/cni"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
@@ -24,8 +25,6 @@ var _ = Describe("Node01Provisioner", func() {
 	BeforeEach(func() {
 		mockCtrl = gomock.NewController(GinkgoT())
 		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
-		opt = NewNode01Provisioner(sshClient, false, false, false, false)
-		AddExpectCalls(sshClient)
 	})
 
 	AfterEach(func() {
@@ -33,7 +32,45 @@ var _ = Describe("Node01Provisioner", func() {
 	})
 
 	It("should execute Node01Provisioner successfully", func() {
-		err := opt.Exec()
+		calico, err := cni.Get(cni.DefaultName)
+		Expect(err).NotTo(HaveOccurred())
+		opt = NewNode01Provisioner(sshClient, false, calico, false, false)
+		AddExpectCalls(sshClient)
+
+		err = opt.Exec()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should set up the CNI from the registry", func() {
+		ovn, err := cni.Get("ovn-kubernetes")
+		Expect(err).NotTo(HaveOccurred())
+		opt = NewNode01Provisioner(sshClient, true, ovn, false, false)
+
+		gomock.InOrder(
+			sshClient.EXPECT().Command("kubeadm init --config /etc/kubernetes/kubeadm_ovn_ipv6.conf -v5 --skip-phases=addon/kube-proxy"),
+			sshClient.EXPECT().Command(gomock.Any()),
+			sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf create -f /etc/kubernetes/ovn-kubernetes_ipv6.yaml"),
+		)
+		sshClient.EXPECT().Command(gomock.Any()).AnyTimes()
+
+		err = opt.Exec()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should run the post install commands of the CNI", func() {
+		flannel, err := cni.Get("flannel")
+		Expect(err).NotTo(HaveOccurred())
+		opt = NewNode01Provisioner(sshClient, false, flannel, false, false)
+
+		gomock.InOrder(
+			sshClient.EXPECT().Command("kubeadm init --config /etc/kubernetes/kubeadm_flannel.conf -v5"),
+			sshClient.EXPECT().Command(gomock.Any()),
+			sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf create -f /etc/kubernetes/flannel.yaml"),
+			sshClient.EXPECT().Command("kubectl --kubeconfig=/etc/kubernetes/admin.conf create -f /etc/kubernetes/knp.yaml"),
+		)
+		sshClient.EXPECT().Command(gomock.Any()).AnyTimes()
+
+		err = opt.Exec()
 		Expect(err).NotTo(HaveOccurred())
 	})
 })
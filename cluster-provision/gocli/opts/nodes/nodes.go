@@ -3,29 +3,17 @@ package nodes
 import (
 	_ "embed"
 	"fmt"
-	"os"
-	"regexp"
 	"runtime"
 
 	"github.com/Masterminds/semver/v3"
-	"github.com/sirupsen/logrus"
 
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
-const (
-	kubevirtProviderEnv = "KUBEVIRT_PROVIDER"
-)
-
-var (
-	//go:embed scripts/setup-bridges.sh
-	setupBridgesScript []byte
-
-	versionRegex = regexp.MustCompile(`.*([0-9]+\.[0-9]+)`)
-)
+//go:embed scripts/setup-bridges.sh
+var setupBridgesScript []byte
 
 type nodesProvisioner struct {
-	k8sVersion            string
 	sshClient             libssh.Client
 	singleStack           bool
 	version               *semver.Version
@@ -34,27 +22,11 @@ type nodesProvisioner struct {
 	reservedSystemCPUs    string
 }
 
-func NewNodesProvisioner(k8sVersion string, sc libssh.Client, singleStack, secondaryNicBridges bool, topologyManagerPolicy, reservedSystemCPUs string) *nodesProvisioner {
-	submatches := versionRegex.FindStringSubmatch(k8sVersion)
-	if len(submatches) != 2 {
-		logrus.Infof("not a parseable semver contained in %q. Trying the %q environment variable", k8sVersion, kubevirtProviderEnv)
-		kubevirtProvider, defined := os.LookupEnv(kubevirtProviderEnv)
-		if !defined {
-			logrus.Fatalf("not a parseable semver contained in %q and %q is not defined", k8sVersion, kubevirtProviderEnv)
-		}
-		submatches = versionRegex.FindStringSubmatch(kubevirtProvider)
-		if len(submatches) != 2 {
-			logrus.Fatalf("not a parseable semver contained in the %q environment variable", kubevirtProviderEnv)
-		}
-	}
-	version, err := semver.NewVersion("v" + submatches[1])
-	if err != nil {
-		logrus.Fatalf("not a parseable semver contained in %q", k8sVersion)
-	}
+// NewNodesProvisioner creates the opt joining a node to the cluster, version is the Kubernetes version of the provider
+func NewNodesProvisioner(version *semver.Version, sc libssh.Client, singleStack, secondaryNicBridges bool, topologyManagerPolicy, reservedSystemCPUs string) *nodesProvisioner {
 	return &nodesProvisioner{
 		sshClient:             sc,
 		singleStack:           singleStack,
-		k8sVersion:            k8sVersion,
 		version:               version,
 		secondaryNicBridges:   secondaryNicBridges,
 		topologyManagerPolicy: topologyManagerPolicy,
@@ -110,7 +82,7 @@ func (n *nodesProvisioner) Exec() error {
 	for _, cmd := range cmds {
 		err := n.sshClient.Command(cmd)
 		if err != nil {
-			return fmt.Errorf("error executing %s: %s", cmd, err)
+			return fmt.Errorf("error provisioning node: %w", err)
 		}
 	}
 	return nil
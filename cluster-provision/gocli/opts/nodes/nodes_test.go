@@ -4,9 +4,11 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"go.uber.org/mock/gomock"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
 
@@ -26,7 +28,7 @@ var _ = Describe("nodes", func() {
 		BeforeEach(func() {
 			mockCtrl = gomock.NewController(GinkgoT())
 			sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
-			opt = NewNodesProvisioner("k8s-1.32", sshClient, false, false, "", "")
+			opt = NewNodesProvisioner(semver.MustParse("1.32.0"), sshClient, false, false, "", "")
 			AddExpectCalls(sshClient)
 		})
 
@@ -42,27 +44,29 @@ var _ = Describe("nodes", func() {
 
 	DescribeTable("calling featureGateFlag",
 		func(k8sVersion, expectedValue string) {
-			np := NewNodesProvisioner(k8sVersion, nil, false, false, "", "")
+			np := NewNodesProvisioner(semver.MustParse(k8sVersion), nil, false, false, "", "")
 			Expect(np.featureGatesFlag()).To(BeEquivalentTo(expectedValue))
 		},
-		Entry("should not add new fg if 1.32", "k8s-1.32", "--feature-gates=NodeSwap=true"),
+		Entry("should not add new fg if 1.32", "1.32.2", "--feature-gates=NodeSwap=true"),
 	)
 
 	When("job name does not contain version", func() {
 		DescribeTable("calling featureGateFlag",
 			func(k8sVersion, expectedValue string) {
-				kvProviderOrig, kvProviderDefined := os.LookupEnv(kubevirtProviderEnv)
+				kvProviderOrig, kvProviderDefined := os.LookupEnv(k8s.ProviderEnv)
 
-				Expect(os.Setenv(kubevirtProviderEnv, k8sVersion)).To(Succeed())
+				Expect(os.Setenv(k8s.ProviderEnv, k8sVersion)).To(Succeed())
 				DeferCleanup(func() {
 					if kvProviderDefined {
-						Expect(os.Setenv(kubevirtProviderEnv, kvProviderOrig)).To(Succeed())
+						Expect(os.Setenv(k8s.ProviderEnv, kvProviderOrig)).To(Succeed())
 					} else {
-						Expect(os.Unsetenv(kubevirtProviderEnv)).To(Succeed())
+						Expect(os.Unsetenv(k8s.ProviderEnv)).To(Succeed())
 					}
 				})
 
-				np := NewNodesProvisioner("name-with-no-version", nil, false, false, "", "")
+				version, err := k8s.ProviderVersion(nil, "name-with-no-version")
+				Expect(err).NotTo(HaveOccurred())
+				np := NewNodesProvisioner(version, nil, false, false, "", "")
 				Expect(np.featureGatesFlag()).To(BeEquivalentTo(expectedValue))
 			},
 			Entry("should not add new fg if 1.32", "k8s-1.32", "--feature-gates=NodeSwap=true"),
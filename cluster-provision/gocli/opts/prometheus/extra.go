@@ -0,0 +1,164 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+const (
+	// ExtraLabel marks the objects which were loaded from the extra directory, a sync deletes the marked objects
+	// which are gone from the directory
+	ExtraLabel = "kubevirtci.io/prometheus-extra"
+	// ExtraDirAnnotation records the extra directory on the dashboards ConfigMap, so it can be synced again
+	ExtraDirAnnotation = "kubevirtci.io/prometheus-extra-dir"
+
+	// extraDashboards holds the dashboards of the extra directory, Grafana shows them in the folder Extra
+	extraDashboards = "grafana-dashboards-extra"
+	namespace       = "monitoring"
+)
+
+// extraKinds are the kinds the extra directory can contain, all of them are part of monitoring.coreos.com/v1
+var extraKinds = []string{"PrometheusRule", "ServiceMonitor", "PodMonitor"}
+
+// Extra holds the rules, monitors and Grafana dashboards of a directory which are deployed on top of the stack
+type Extra struct {
+	dir    string
+	bundle *common.Bundle
+}
+
+// LoadExtra reads the PrometheusRules, ServiceMonitors and PodMonitors of all .yaml files and the Grafana dashboards
+// of all .json files below dir. The dashboards are put into one ConfigMap, which also records dir
+func LoadExtra(dir string) (*Extra, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	fsys := os.DirFS(dir)
+	bundle, err := common.LoadBundle(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", dir, err)
+	}
+	for _, obj := range bundle.Objects() {
+		if obj.GroupVersionKind().Group != "monitoring.coreos.com" || !slices.Contains(extraKinds, obj.GetKind()) {
+			return nil, fmt.Errorf("%s %s in %s is neither a PrometheusRule, ServiceMonitor nor PodMonitor", obj.GetKind(), obj.GetName(), dir)
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+	}
+
+	dashboards := map[string]interface{}{}
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		key := filepath.Base(path)
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return fmt.Errorf("dashboard %s can not be provisioned: %v", path, errs)
+		}
+		if _, exists := dashboards[key]; exists {
+			return fmt.Errorf("dashboard %s exists more than once in %s", key, dir)
+		}
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(data) {
+			return fmt.Errorf("dashboard %s is not valid JSON", path)
+		}
+		dashboards[key] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetName(extraDashboards)
+	configMap.SetNamespace(namespace)
+	configMap.SetAnnotations(map[string]string{ExtraDirAnnotation: dir})
+	if err := unstructured.SetNestedField(configMap.Object, dashboards, "data"); err != nil {
+		return nil, err
+	}
+	bundle.With(configMap)
+	if err := bundle.Mutate(withExtraLabel); err != nil {
+		return nil, err
+	}
+	return &Extra{dir: dir, bundle: bundle}, nil
+}
+
+// LoadRecordedExtra loads the extra directory the cluster was last synced with, it returns nil if there is none
+func LoadRecordedExtra(client k8s.K8sDynamicClient) (*Extra, error) {
+	configMap, err := client.Get(corev1.SchemeGroupVersion.WithKind("ConfigMap"), extraDashboards, namespace)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dir := configMap.GetAnnotations()[ExtraDirAnnotation]
+	if dir == "" {
+		return nil, nil
+	}
+	return LoadExtra(dir)
+}
+
+// Dir returns the absolute path of the extra directory
+func (e *Extra) Dir() string {
+	return e.dir
+}
+
+// apply deploys the content of the directory and deletes the marked objects which are not part of it anymore
+func (e *Extra) apply(client k8s.K8sDynamicClient) error {
+	if err := e.bundle.Apply(client); err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, obj := range e.bundle.Objects() {
+		wanted[obj.GetKind()+"/"+obj.GetNamespace()+"/"+obj.GetName()] = true
+	}
+	for _, kind := range extraKinds {
+		gvk := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: kind}
+		list, err := client.List(gvk, "")
+		if err != nil {
+			return err
+		}
+		for _, obj := range list.Items {
+			if _, marked := obj.GetLabels()[ExtraLabel]; !marked || wanted[kind+"/"+obj.GetNamespace()+"/"+obj.GetName()] {
+				continue
+			}
+			if err := client.Delete(gvk, obj.GetName(), obj.GetNamespace()); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func withExtraLabel(obj *unstructured.Unstructured) error {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[ExtraLabel] = "true"
+	obj.SetLabels(labels)
+	return nil
+}
@@ -1,14 +1,16 @@
 package prometheus
 
 import (
-	"bytes"
 	"embed"
+	"encoding/json"
 	"fmt"
-	"io/fs"
-	"path/filepath"
 
 	"github.com/sirupsen/logrus"
-
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/logging"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 )
 
@@ -18,122 +20,119 @@ var f embed.FS
 type prometheusOpt struct {
 	grafanaEnabled      bool
 	alertmanagerEnabled bool
+	lokiEnabled         bool
+	extra               *Extra
 
 	client k8s.K8sDynamicClient
 }
 
-func NewPrometheusOpt(c k8s.K8sDynamicClient, grafanaEnabled, alertmanagerEnabled bool) *prometheusOpt {
+// NewPrometheusOpt creates an opt which deploys the Prometheus operator, with lokiEnabled Grafana gets a Loki
+// datasource for the logs the logging opt collects. The rules, monitors and dashboards of extra are deployed on
+// top, extra can be nil
+func NewPrometheusOpt(c k8s.K8sDynamicClient, grafanaEnabled, alertmanagerEnabled, lokiEnabled bool, extra *Extra) *prometheusOpt {
 	return &prometheusOpt{
 		grafanaEnabled:      grafanaEnabled,
 		alertmanagerEnabled: alertmanagerEnabled,
+		lokiEnabled:         lokiEnabled,
+		extra:               extra,
 		client:              c,
 	}
 }
 
-func (o *prometheusOpt) Exec() error {
-	for _, dir := range []string{"prometheus-operator", "prometheus", "monitors", "kube-state-metrics", "node-exporter"} {
-		err := fs.WalkDir(f, "manifests/"+dir, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if !d.IsDir() && filepath.Ext(path) == ".yaml" {
-				yamlData, err := f.ReadFile(path)
-				if err != nil {
-					return err
-				}
-				yamlDocs := bytes.Split(yamlData, []byte("\n---\n"))
-				for _, yamlDoc := range yamlDocs {
-					if len(yamlDoc) == 0 {
-						continue
-					}
+func (o *prometheusOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "prometheus"}
+}
 
-					obj, err := k8s.SerializeIntoObject(yamlDoc)
-					if err != nil {
-						logrus.Info(err.Error())
-						continue
-					}
-					if err := o.client.Apply(obj); err != nil {
-						return fmt.Errorf("error applying manifest %s", err)
-					}
-				}
-			}
-			return nil
-		})
+func (o *prometheusOpt) Exec() error {
+	dirs := []string{"prometheus-operator", "prometheus", "monitors", "kube-state-metrics", "node-exporter"}
+	if o.alertmanagerEnabled {
+		dirs = append(dirs, "alertmanager", "alertmanager-rules")
+	}
+	if o.grafanaEnabled {
+		dirs = append(dirs, "grafana")
+	}
+	for i, dir := range dirs {
+		dirs[i] = "manifests/" + dir
+	}
 
-		if err != nil {
+	bundle, err := common.LoadBundle(f, dirs...)
+	if err != nil {
+		return err
+	}
+	if o.lokiEnabled {
+		if err := bundle.Mutate(withLokiDatasource); err != nil {
 			return err
 		}
 	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
 
-	if o.alertmanagerEnabled {
-		for _, dir := range []string{"alertmanager", "alertmanager-rules"} {
-			err := fs.WalkDir(f, "manifests/"+dir, func(path string, d fs.DirEntry, err error) error {
-				if err != nil {
-					return err
-				}
-				if !d.IsDir() && filepath.Ext(path) == ".yaml" {
-					yamlData, err := f.ReadFile(path)
-					if err != nil {
-						return err
-					}
-					yamlDocs := bytes.Split(yamlData, []byte("---\n"))
-					for _, yamlDoc := range yamlDocs {
-						if len(yamlDoc) == 0 {
-							continue
-						}
-
-						obj, err := k8s.SerializeIntoObject(yamlDoc)
-						if err != nil {
-							logrus.Info(err.Error())
-							continue
-						}
-						if err := o.client.Apply(obj); err != nil {
-							return fmt.Errorf("error applying manifest %s", err)
-						}
-					}
-				}
-				return nil
-			})
+	if o.extra != nil {
+		return o.extra.apply(o.client)
+	}
+	return nil
+}
 
-			if err != nil {
-				return err
-			}
+// Sync deploys the extra directory again, rules, monitors and dashboards which were removed from it are deleted.
+// Without an extra directory the one the cluster was last synced with is used
+func (o *prometheusOpt) Sync() error {
+	extra := o.extra
+	if extra == nil {
+		var err error
+		if extra, err = LoadRecordedExtra(o.client); err != nil {
+			return err
+		}
+		if extra == nil {
+			return fmt.Errorf("prometheus was deployed without --prometheus-extra, there is no directory to sync")
 		}
 	}
+	if err := extra.apply(o.client); err != nil {
+		return err
+	}
+	logrus.Infof("Synced %s", extra.Dir())
+	return nil
+}
 
-	if o.grafanaEnabled {
-		err := fs.WalkDir(f, "manifests/grafana", func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if !d.IsDir() && filepath.Ext(path) == ".yaml" {
-				yamlData, err := f.ReadFile(path)
-				if err != nil {
-					return err
-				}
-				yamlDocs := bytes.Split(yamlData, []byte("---\n"))
-				for _, yamlDoc := range yamlDocs {
-					if len(yamlDoc) == 0 {
-						continue
-					}
+// Uninstall deletes all components, including alertmanager and grafana if they were not enabled
+func (o *prometheusOpt) Uninstall() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
 
-					obj, err := k8s.SerializeIntoObject(yamlDoc)
-					if err != nil {
-						logrus.Info(err.Error())
-						continue
-					}
-					if err := o.client.Apply(obj); err != nil {
-						return fmt.Errorf("error applying manifest %s", err)
-					}
-				}
-			}
-			return nil
-		})
+func (o *prometheusOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "prometheus-operator", "monitoring", k8s.DeploymentRolledOut)
+}
 
-		if err != nil {
-			return err
-		}
+// withLokiDatasource adds Loki to the datasources Grafana is provisioned with
+func withLokiDatasource(obj *unstructured.Unstructured) error {
+	if obj.GetKind() != "Secret" || obj.GetName() != "grafana-datasources" {
+		return nil
 	}
-
-	return nil
+	raw, _, err := unstructured.NestedString(obj.Object, "stringData", "datasources.yaml")
+	if err != nil {
+		return err
+	}
+	datasources := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(raw), &datasources); err != nil {
+		return err
+	}
+	list, _ := datasources["datasources"].([]interface{})
+	datasources["datasources"] = append(list, map[string]interface{}{
+		"access":   "proxy",
+		"editable": false,
+		"name":     "loki",
+		"orgId":    1,
+		"type":     "loki",
+		"url":      logging.LokiURL,
+		"version":  1,
+	})
+	updated, err := json.MarshalIndent(datasources, "", "    ")
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(obj.Object, string(updated), "stringData", "datasources.yaml")
 }
@@ -1,10 +1,15 @@
 package prometheus
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/logging"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 )
 
@@ -21,11 +26,86 @@ var _ = Describe("PrometheusOpt", func() {
 
 	BeforeEach(func() {
 		client = k8s.NewTestClient()
-		opt = NewPrometheusOpt(client, true, true)
+		opt = NewPrometheusOpt(client, true, true, false, nil)
 	})
 
 	It("should execute PrometheusOpt successfully", func() {
 		err := opt.Exec()
 		Expect(err).NotTo(HaveOccurred())
 	})
+
+	It("should add the Loki datasource to Grafana", func() {
+		Expect(NewPrometheusOpt(client, true, false, true, nil).Exec()).To(Succeed())
+
+		secret, err := client.Get(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, "grafana-datasources", "monitoring")
+		Expect(err).NotTo(HaveOccurred())
+		datasources, _, err := unstructured.NestedString(secret.Object, "stringData", "datasources.yaml")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(datasources).To(ContainSubstring(`"name": "prometheus"`))
+		Expect(datasources).To(ContainSubstring(`"url": "` + logging.LokiURL + `"`))
+	})
+
+	Context("with an extra directory", func() {
+		const rule = `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: kubevirt-dev
+spec:
+  groups: []
+`
+		const monitor = `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: virt-handler
+  namespace: kubevirt
+spec:
+  endpoints: []
+`
+		var dir string
+
+		ruleGVK := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+		monitorGVK := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(dir, "monitors"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "rule.yaml"), []byte(rule), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "monitors", "virt-handler.yaml"), []byte(monitor), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "kubevirt.json"), []byte(`{"title": "KubeVirt"}`), 0644)).To(Succeed())
+		})
+
+		It("should deploy the rules, monitors and dashboards and sync them", func() {
+			extra, err := LoadExtra(dir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(NewPrometheusOpt(client, true, false, false, extra).Exec()).To(Succeed())
+
+			_, err = client.Get(ruleGVK, "kubevirt-dev", namespace)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Get(monitorGVK, "virt-handler", "kubevirt")
+			Expect(err).NotTo(HaveOccurred())
+			dashboards, err := client.Get(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, extraDashboards, namespace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dashboards.GetAnnotations()).To(HaveKeyWithValue(ExtraDirAnnotation, dir))
+			Expect(dashboards.Object["data"]).To(HaveKeyWithValue("kubevirt.json", `{"title": "KubeVirt"}`))
+
+			Expect(os.Remove(filepath.Join(dir, "rule.yaml"))).To(Succeed())
+			Expect(NewPrometheusOpt(client, false, false, false, nil).Sync()).To(Succeed())
+
+			_, err = client.Get(ruleGVK, "kubevirt-dev", namespace)
+			Expect(err).To(HaveOccurred())
+			_, err = client.Get(monitorGVK, "virt-handler", "kubevirt")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should refuse other kinds", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n"), 0644)).To(Succeed())
+			_, err := LoadExtra(dir)
+			Expect(err).To(MatchError(ContainSubstring("Deployment app")))
+		})
+
+		It("should fail to sync without an extra directory", func() {
+			Expect(opt.Exec()).To(Succeed())
+			Expect(opt.Sync()).To(MatchError(ContainSubstring("no directory to sync")))
+		})
+	})
 })
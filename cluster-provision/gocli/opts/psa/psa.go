@@ -1,34 +1,136 @@
 package psa
 
 import (
-	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	admissionv1 "k8s.io/pod-security-admission/admission/api/v1"
+	psaapi "k8s.io/pod-security-admission/api"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+	"sigs.k8s.io/yaml"
 )
 
-//go:embed manifests/psa.yaml
-var psa []byte
+// configPath is the admission configuration kube-apiserver is started with, the kubeadm configs of the providers mount it
+const configPath = "/etc/kubernetes/psa.yaml"
+
+// DefaultLevel is enforced, audited and warned about by default
+const DefaultLevel = "restricted"
+
+// DefaultExemptNamespaces are not checked by default. Hopefully this will not be needed in future. Add your favorite namespace to be ignored and your operator not broken
+var DefaultExemptNamespaces = []string{"kube-system", "default", "istio-operator", "istio-system", "nfs-csi", "monitoring", "logging", "rook-ceph", "cluster-network-addons", "sonobuoy"}
+
+// Config holds the Pod Security Standard levels of the cluster and the namespaces which are exempt from them
+type Config struct {
+	Enforce          string
+	Audit            string
+	Warn             string
+	ExemptNamespaces []string
+}
+
+// DefaultConfig enforces the restricted level everywhere but in DefaultExemptNamespaces
+func DefaultConfig() Config {
+	return Config{
+		Enforce:          DefaultLevel,
+		Audit:            DefaultLevel,
+		Warn:             DefaultLevel,
+		ExemptNamespaces: DefaultExemptNamespaces,
+	}
+}
+
+// admissionConfiguration is the part of apiserver.config.k8s.io/v1 AdmissionConfiguration gocli renders and checks
+type admissionConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+	Plugins         []admissionPlugin `json:"plugins"`
+}
+
+type admissionPlugin struct {
+	Name          string          `json:"name"`
+	Configuration json.RawMessage `json:"configuration,omitempty"`
+	Path          string          `json:"path,omitempty"`
+}
+
+// Render returns the AdmissionConfiguration of the levels and exemptions
+func (c Config) Render() ([]byte, error) {
+	for _, level := range []struct{ mode, level string }{{"enforce", c.Enforce}, {"audit", c.Audit}, {"warn", c.Warn}} {
+		if _, err := psaapi.ParseLevel(level.level); err != nil {
+			return nil, fmt.Errorf("invalid %s level: %w", level.mode, err)
+		}
+	}
+
+	podSecurity, err := json.Marshal(admissionv1.PodSecurityConfiguration{
+		TypeMeta: metav1.TypeMeta{APIVersion: "pod-security.admission.config.k8s.io/v1", Kind: "PodSecurityConfiguration"},
+		Defaults: admissionv1.PodSecurityDefaults{
+			Enforce:        c.Enforce,
+			EnforceVersion: psaapi.VersionLatest,
+			Audit:          c.Audit,
+			AuditVersion:   psaapi.VersionLatest,
+			Warn:           c.Warn,
+			WarnVersion:    psaapi.VersionLatest,
+		},
+		Exemptions: admissionv1.PodSecurityExemptions{
+			Namespaces: c.ExemptNamespaces,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(admissionConfiguration{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiserver.config.k8s.io/v1", Kind: "AdmissionConfiguration"},
+		Plugins:  []admissionPlugin{{Name: "PodSecurity", Configuration: podSecurity}},
+	})
+}
+
+// ValidateAdmissionConfig checks that a user supplied AdmissionConfiguration configures the PodSecurity plugin
+// with valid levels, so mistakes show up before kube-apiserver refuses to start
+func ValidateAdmissionConfig(data []byte) error {
+	config := admissionConfiguration{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("error parsing the admission configuration: %w", err)
+	}
+	if config.APIVersion != "apiserver.config.k8s.io/v1" || config.Kind != "AdmissionConfiguration" {
+		return fmt.Errorf("the admission configuration has to be an apiserver.config.k8s.io/v1 AdmissionConfiguration")
+	}
+	for _, plugin := range config.Plugins {
+		if plugin.Name != "PodSecurity" {
+			continue
+		}
+		if plugin.Path != "" {
+			return fmt.Errorf("the PodSecurity configuration has to be inline, %s is not available on the nodes", plugin.Path)
+		}
+		podSecurity := admissionv1.PodSecurityConfiguration{}
+		if err := json.Unmarshal(plugin.Configuration, &podSecurity); err != nil {
+			return fmt.Errorf("error parsing the PodSecurity configuration: %w", err)
+		}
+		for _, level := range []string{podSecurity.Defaults.Enforce, podSecurity.Defaults.Audit, podSecurity.Defaults.Warn} {
+			if level == "" {
+				continue
+			}
+			if _, err := psaapi.ParseLevel(level); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("the admission configuration does not configure the PodSecurity plugin")
+}
 
 type psaOpt struct {
 	sshClient libssh.Client
+	config    []byte
 }
 
-func NewPsaOpt(sc libssh.Client) *psaOpt {
+// NewPsaOpt creates an opt which writes the admission configuration kube-apiserver is started with,
+// config is either rendered from a Config or a validated user supplied AdmissionConfiguration
+func NewPsaOpt(sc libssh.Client, config []byte) *psaOpt {
 	return &psaOpt{
 		sshClient: sc,
+		config:    config,
 	}
 }
 
+// Exec writes the configuration before kubeadm init starts kube-apiserver, which reads it on start
 func (o *psaOpt) Exec() error {
-	cmds := []string{
-		"rm /etc/kubernetes/psa.yaml",
-		"echo '" + string(psa) + "' | sudo tee /etc/kubernetes/psa.yaml > /dev/null",
-	}
-	for _, cmd := range cmds {
-		if err := o.sshClient.Command(cmd); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return o.sshClient.Command("echo " + base64.StdEncoding.EncodeToString(o.config) + " | base64 -d | sudo tee " + configPath + " > /dev/null")
 }
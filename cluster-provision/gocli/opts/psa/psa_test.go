@@ -15,19 +15,58 @@ func TestRealTimeOpt(t *testing.T) {
 }
 
 var _ = Describe("PsaOpt", func() {
-	var (
-		sshClient *kubevirtcimocks.MockSSHClient
-		opt       *psaOpt
-	)
+	var sshClient *kubevirtcimocks.MockSSHClient
 
 	BeforeEach(func() {
 		sshClient = kubevirtcimocks.NewMockSSHClient(gomock.NewController(GinkgoT()))
-		opt = NewPsaOpt(sshClient)
-		AddExpectCalls(sshClient)
 	})
 
 	It("should execute PsaOpt successfully", func() {
-		err := opt.Exec()
+		config, err := DefaultConfig().Render()
+		Expect(err).NotTo(HaveOccurred())
+		AddExpectCalls(sshClient, config)
+
+		err = NewPsaOpt(sshClient, config).Exec()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should render the levels and exemptions", func() {
+		config, err := Config{Enforce: "baseline", Audit: "restricted", Warn: "restricted", ExemptNamespaces: []string{"kube-system", "kubevirt"}}.Render()
 		Expect(err).NotTo(HaveOccurred())
+		Expect(ValidateAdmissionConfig(config)).To(Succeed())
+		Expect(string(config)).To(ContainSubstring("kind: AdmissionConfiguration"))
+		Expect(string(config)).To(ContainSubstring("enforce: baseline"))
+		Expect(string(config)).To(ContainSubstring("- kubevirt"))
+	})
+
+	It("should refuse unknown levels", func() {
+		config := DefaultConfig()
+		config.Audit = "strict"
+		_, err := config.Render()
+		Expect(err).To(MatchError(ContainSubstring("invalid audit level")))
 	})
+
+	DescribeTable("should validate user supplied admission configurations", func(config, expected string) {
+		err := ValidateAdmissionConfig([]byte(config))
+		if expected == "" {
+			Expect(err).NotTo(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		}
+	},
+		Entry("valid", `apiVersion: apiserver.config.k8s.io/v1
+kind: AdmissionConfiguration
+plugins:
+- name: PodSecurity
+  configuration:
+    apiVersion: pod-security.admission.config.k8s.io/v1
+    kind: PodSecurityConfiguration
+    defaults:
+      enforce: baseline
+`, ""),
+		Entry("other kind", "apiVersion: v1\nkind: ConfigMap\n", "has to be an apiserver.config.k8s.io/v1 AdmissionConfiguration"),
+		Entry("without PodSecurity", "apiVersion: apiserver.config.k8s.io/v1\nkind: AdmissionConfiguration\nplugins: []\n", "does not configure the PodSecurity plugin"),
+		Entry("configuration in a file", "apiVersion: apiserver.config.k8s.io/v1\nkind: AdmissionConfiguration\nplugins:\n- name: PodSecurity\n  path: /tmp/podsecurity.yaml\n", "has to be inline"),
+		Entry("unknown level", "apiVersion: apiserver.config.k8s.io/v1\nkind: AdmissionConfiguration\nplugins:\n- name: PodSecurity\n  configuration:\n    defaults:\n      warn: strict\n", "strict"),
+	)
 })
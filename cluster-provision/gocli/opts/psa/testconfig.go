@@ -1,8 +1,11 @@
 package psa
 
-import kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+import (
+	"encoding/base64"
 
-func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient) {
-	sshClient.EXPECT().Command("rm /etc/kubernetes/psa.yaml")
-	sshClient.EXPECT().Command("echo '" + string(psa) + "' | sudo tee /etc/kubernetes/psa.yaml > /dev/null")
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient, config []byte) {
+	sshClient.EXPECT().Command("echo " + base64.StdEncoding.EncodeToString(config) + " | base64 -d | sudo tee /etc/kubernetes/psa.yaml > /dev/null")
 }
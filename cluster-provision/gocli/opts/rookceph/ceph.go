@@ -1,125 +1,191 @@
 package rookceph
 
 import (
-	"bytes"
+	"context"
 	"embed"
 	"fmt"
-	"io/fs"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
-	"github.com/sirupsen/logrus"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/snapshotcontroller"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
-//go:embed manifests/*
+//go:embed manifests/* filesystem/* objectstore/*
 var f embed.FS
 
+// DefaultOSDSize is the size of the block device every node is started with for Ceph
+const DefaultOSDSize = "30Gi"
+
+// countBlockDevicesCmd counts the disks without partition table and filesystem, rook turns all of them into OSDs
+const countBlockDevicesCmd = `lsblk -dnP -o TYPE,PTTYPE,FSTYPE | grep -c 'TYPE="disk" PTTYPE="" FSTYPE=""' || true`
+
+// Config describes the pools and storage classes of the Ceph cluster
+type Config struct {
+	// Replicas is the replication size of all pools, it must not be larger than the number of OSDs
+	Replicas int
+	// Filesystem creates the myfs CephFS and its rook-cephfs StorageClass
+	Filesystem bool
+	// ObjectStore creates the my-store object store and the rook-ceph-bucket StorageClass for ObjectBucketClaims
+	ObjectStore bool
+	// DefaultStorageClass makes rook-ceph-block the default StorageClass instead of local
+	DefaultStorageClass bool
+}
+
+// DefaultConfig is a single replica block pool which becomes the default storage
+func DefaultConfig() Config {
+	return Config{
+		Replicas:            1,
+		DefaultStorageClass: true,
+	}
+}
+
 type cephOpt struct {
 	client    k8s.K8sDynamicClient
 	sshClient libssh.Client
+	config    Config
 }
 
-func NewCephOpt(c k8s.K8sDynamicClient, sshClient libssh.Client) *cephOpt {
+func NewCephOpt(c k8s.K8sDynamicClient, sshClient libssh.Client, config Config) *cephOpt {
 	return &cephOpt{
 		client:    c,
 		sshClient: sshClient,
+		config:    config,
 	}
 }
 
+func (o *cephOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "ceph", Dependencies: []string{snapshotcontroller.Name}}
+}
+
 func (o *cephOpt) Exec() error {
-	err := fs.WalkDir(f, "manifests", func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() && filepath.Ext(path) == ".yaml" {
-			yamlData, err := f.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			yamlDocs := bytes.Split(yamlData, []byte("---\n"))
-			for _, yamlDoc := range yamlDocs {
-				if len(yamlDoc) == 0 {
-					continue
-				}
+	if err := o.checkBlockDevices(); err != nil {
+		return err
+	}
 
-				obj, err := k8s.SerializeIntoObject(yamlDoc)
-				if err != nil {
-					logrus.WithField("path", path).Info(err.Error())
-					continue
-				}
-				if err := o.client.Apply(obj); err != nil {
-					return fmt.Errorf("error applying manifest %q: %v", path, err)
-				}
-			}
-		}
-		return nil
-	})
+	paths := []string{"manifests"}
+	if o.config.Filesystem {
+		paths = append(paths, "filesystem")
+	}
+	if o.config.ObjectStore {
+		paths = append(paths, "objectstore")
+	}
+	bundle, err := common.LoadBundle(f, paths...)
 	if err != nil {
 		return err
 	}
+	if err := bundle.Mutate(withReplicas(o.config.Replicas)); err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
 
-	blockpool := &cephv1.CephBlockPool{}
-
-	operation := func() error {
-		obj, err := o.client.Get(schema.GroupVersionKind{
-			Group:   "ceph.rook.io",
-			Version: "v1",
-			Kind:    "CephBlockPool",
-		}, "replicapool", "rook-ceph")
-
-		if err != nil {
-			logrus.Errorf("Attempt failed: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	if err := k8s.WaitForPhase(ctx, o.client, cephv1.SchemeGroupVersion.WithKind("CephBlockPool"), "replicapool", "rook-ceph", "Ready"); err != nil {
+		return err
+	}
+	if o.config.Filesystem {
+		if err := k8s.WaitForPhase(ctx, o.client, cephv1.SchemeGroupVersion.WithKind("CephFilesystem"), "myfs", "rook-ceph", "Ready"); err != nil {
 			return err
 		}
-
-		err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, blockpool)
-		if err != nil {
-			logrus.Errorf("Attempt failed: %v", err)
+	}
+	if o.config.ObjectStore {
+		if err := k8s.WaitForPhase(ctx, o.client, cephv1.SchemeGroupVersion.WithKind("CephObjectStore"), "my-store", "rook-ceph", "Ready"); err != nil {
 			return err
 		}
+	}
 
-		if blockpool.Status == nil {
-			err := fmt.Errorf("ceph block pool: no status yet")
-			logrus.Info(err)
-			return err
-		}
+	if !o.config.DefaultStorageClass {
+		return nil
+	}
+	return o.setDefaultStorageClass("rook-ceph-block", "local")
+}
 
-		if blockpool.Status.Phase != "Ready" {
-			err := fmt.Errorf("ceph block pool phase=%q: CephBlockPool=%+v", blockpool.Status.Phase, blockpool)
-			logrus.Info(err)
+// Uninstall makes local the default storage class again, if ceph was made the default, and deletes the ceph cluster before the operator
+func (o *cephOpt) Uninstall() error {
+	if o.config.DefaultStorageClass {
+		if err := o.setDefaultStorageClass("local", "rook-ceph-block"); err != nil {
 			return err
 		}
+	}
 
-		logrus.Infof("ceph block pool phase=%q: CephBlockPool=%+v", blockpool.Status.Phase, blockpool)
-
-		return nil
+	bundle, err := common.LoadBundle(f, "manifests", "filesystem", "objectstore")
+	if err != nil {
+		return err
 	}
+	return bundle.Delete(o.client)
+}
+func (o *cephOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, cephv1.SchemeGroupVersion.WithKind("CephBlockPool"), "replicapool", "rook-ceph", k8s.PhaseIs("Ready"))
+}
 
-	maxElapsedTime := 10 * time.Minute
-	err = backoff.Retry(operation, backoff.NewExponentialBackOff(
-		backoff.WithInitialInterval(45*time.Second),
-		backoff.WithMaxInterval(90*time.Second),
-		backoff.WithMaxElapsedTime(maxElapsedTime),
-	))
+// checkBlockDevices fails if node01 has no empty disk for an OSD, the block pool would never become ready then. Only
+// clusters which were started with --enable-ceph have one
+func (o *cephOpt) checkBlockDevices() error {
+	out, err := o.sshClient.CommandWithNoStdOut(countBlockDevicesCmd)
 	if err != nil {
-		return fmt.Errorf("operation timed out after %s: %w", maxElapsedTime, err)
+		return fmt.Errorf("error looking for the block devices of the Ceph OSDs: %w", err)
 	}
+	count, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return fmt.Errorf("unexpected number of block devices %q: %w", out, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("node01 has no empty block device for a Ceph OSD, ceph can only be enabled on clusters which were started with --enable-ceph")
+	}
+	return nil
+}
 
+func (o *cephOpt) setDefaultStorageClass(defaultClass, previousClass string) error {
 	cmds := []string{
-		`kubectl --kubeconfig /etc/kubernetes/admin.conf patch storageclass local -p '{"metadata": {"annotations":{"storageclass.kubernetes.io/is-default-class":"false"}}}'`,
-		`kubectl --kubeconfig /etc/kubernetes/admin.conf patch storageclass rook-ceph-block -p '{"metadata": {"annotations":{"storageclass.kubernetes.io/is-default-class":"true"}}}'`,
+		`kubectl --kubeconfig /etc/kubernetes/admin.conf patch storageclass ` + previousClass + ` -p '{"metadata": {"annotations":{"storageclass.kubernetes.io/is-default-class":"false"}}}'`,
+		`kubectl --kubeconfig /etc/kubernetes/admin.conf patch storageclass ` + defaultClass + ` -p '{"metadata": {"annotations":{"storageclass.kubernetes.io/is-default-class":"true"}}}'`,
 	}
 	for _, cmd := range cmds {
 		if err := o.sshClient.Command(cmd); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
+
+// withReplicas sets the replication size of all pools, single replica pools are allowed as none of them requires
+// a safe replica size
+func withReplicas(replicas int) common.Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		switch obj.GetKind() {
+		case "CephCluster":
+			return unstructured.SetNestedField(obj.Object, strconv.Itoa(replicas), "spec", "cephConfig", "global", "osd_pool_default_size")
+		case "CephBlockPool":
+			return unstructured.SetNestedField(obj.Object, int64(replicas), "spec", "replicated", "size")
+		case "CephObjectStore":
+			if err := unstructured.SetNestedField(obj.Object, int64(replicas), "spec", "metadataPool", "replicated", "size"); err != nil {
+				return err
+			}
+			return unstructured.SetNestedField(obj.Object, int64(replicas), "spec", "dataPool", "replicated", "size")
+		case "CephFilesystem":
+			if err := unstructured.SetNestedField(obj.Object, int64(replicas), "spec", "metadataPool", "replicated", "size"); err != nil {
+				return err
+			}
+			dataPools, _, err := unstructured.NestedSlice(obj.Object, "spec", "dataPools")
+			if err != nil {
+				return err
+			}
+			for _, pool := range dataPools {
+				if err := unstructured.SetNestedField(pool.(map[string]interface{}), int64(replicas), "replicated", "size"); err != nil {
+					return err
+				}
+			}
+			return unstructured.SetNestedSlice(obj.Object, dataPools, "spec", "dataPools")
+		}
+		return nil
+	}
+}
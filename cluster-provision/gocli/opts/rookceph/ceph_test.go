@@ -8,7 +8,11 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"go.uber.org/mock/gomock"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
 	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
 )
@@ -21,23 +25,55 @@ func TestNfsCsiOpt(t *testing.T) {
 var _ = Describe("CephOpt", func() {
 	var (
 		k8sClient k8s.K8sDynamicClient
-		opt       *cephOpt
 		sshClient *kubevirtcimocks.MockSSHClient
 	)
 
 	BeforeEach(func() {
 		sshClient = kubevirtcimocks.NewMockSSHClient(gomock.NewController(GinkgoT()))
-		AddExpectCalls(sshClient)
-		r := k8s.NewReactorConfig("create", "cephblockpools", CephReactor)
-		k8sClient = k8s.NewTestClient(r)
-		opt = NewCephOpt(k8sClient, sshClient)
+		k8sClient = k8s.NewTestClient(
+			k8s.NewReactorConfig("create", "cephblockpools", CephReactor),
+			k8s.NewReactorConfig("create", "cephfilesystems", CephReactor),
+			k8s.NewReactorConfig("create", "cephobjectstores", CephReactor),
+		)
 	})
 
 	It("should execute Ceph successfully", func() {
+		AddExpectCalls(sshClient)
+		opt := NewCephOpt(k8sClient, sshClient, DefaultConfig())
 		err := opt.Exec()
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("should refuse clusters without a block device for the OSDs", func() {
+		AddBlockDevicesExpectCalls(sshClient, 0)
+		opt := NewCephOpt(k8sClient, sshClient, DefaultConfig())
+		Expect(opt.Exec()).To(MatchError(ContainSubstring("node01 has no empty block device for a Ceph OSD")))
+	})
+
+	It("should create the filesystem and object store with the given replicas", func() {
+		AddBlockDevicesExpectCalls(sshClient, 2)
+		opt := NewCephOpt(k8sClient, sshClient, Config{Replicas: 2, Filesystem: true, ObjectStore: true})
+		Expect(opt.Exec()).To(Succeed())
+
+		pool, err := k8sClient.Get(cephv1.SchemeGroupVersion.WithKind("CephBlockPool"), "replicapool", "rook-ceph")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pool.Object["spec"]).To(HaveKeyWithValue("replicated", HaveKeyWithValue("size", BeEquivalentTo(2))))
+
+		fs, err := k8sClient.Get(cephv1.SchemeGroupVersion.WithKind("CephFilesystem"), "myfs", "rook-ceph")
+		Expect(err).NotTo(HaveOccurred())
+		dataPools, _, err := unstructured.NestedSlice(fs.Object, "spec", "dataPools")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dataPools[0]).To(HaveKeyWithValue("replicated", HaveKeyWithValue("size", BeEquivalentTo(2))))
+
+		_, err = k8sClient.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "rook-cephfs", "")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sClient.Get(storagev1.SchemeGroupVersion.WithKind("StorageClass"), "rook-ceph-bucket", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		_, err = k8sClient.Get(cephv1.SchemeGroupVersion.WithKind("CephObjectStore"), "my-store", "rook-ceph")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
 })
 
 var _ = Describe("BackOff", func() {
@@ -1,6 +1,8 @@
 package rookceph
 
 import (
+	"fmt"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stesting "k8s.io/client-go/testing"
@@ -20,6 +22,11 @@ var CephReactor = func(action k8stesting.Action) (bool, runtime.Object, error) {
 }
 
 func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient) {
+	AddBlockDevicesExpectCalls(sshClient, 1)
 	sshClient.EXPECT().Command(`kubectl --kubeconfig /etc/kubernetes/admin.conf patch storageclass local -p '{"metadata": {"annotations":{"storageclass.kubernetes.io/is-default-class":"false"}}}'`)
 	sshClient.EXPECT().Command(`kubectl --kubeconfig /etc/kubernetes/admin.conf patch storageclass rook-ceph-block -p '{"metadata": {"annotations":{"storageclass.kubernetes.io/is-default-class":"true"}}}'`)
 }
+
+func AddBlockDevicesExpectCalls(sshClient *kubevirtcimocks.MockSSHClient, count int) {
+	sshClient.EXPECT().CommandWithNoStdOut(`lsblk -dnP -o TYPE,PTTYPE,FSTYPE | grep -c 'TYPE="disk" PTTYPE="" FSTYPE=""' || true`).Return(fmt.Sprintf("%d\n", count), nil)
+}
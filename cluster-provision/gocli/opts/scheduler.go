@@ -0,0 +1,268 @@
+package opts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// Descriptor tells the scheduler how an opt relates to the other opts
+type Descriptor struct {
+	// Name identifies the opt in dependencies, conflicts and reports
+	Name string
+	// Dependencies have to be scheduled as well and finish successfully before the opt starts
+	Dependencies []string
+	// Conflicts must not be scheduled together with the opt
+	Conflicts []string
+	// KubernetesVersion is a semver constraint like ">=1.30" the Kubernetes version of the cluster has to satisfy,
+	// the opt is skipped with a warning otherwise
+	KubernetesVersion string
+}
+
+// ScheduledOpt is an opt which can be run by the scheduler
+type ScheduledOpt interface {
+	Opt
+	Descriptor() Descriptor
+}
+
+// Result reports how a scheduled opt went
+type Result struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+	// Skipped is set when the opt did not run, because one of its dependencies failed or because
+	// it does not support the Kubernetes version of the cluster
+	Skipped bool
+	// Reason explains why the opt was skipped
+	Reason string
+}
+
+// Schedule validates the opts and runs them concurrently on a cluster of the given Kubernetes version, every opt
+// starts as soon as all of its dependencies finished. Opts which do not support the version are skipped together
+// with the opts depending on them, a nil version skips this check. Opts whose dependencies failed are skipped,
+// all other opts are run to completion. The results are returned in the order the opts were passed in and the
+// errors of all failed opts are joined
+func Schedule(version *semver.Version, opts ...ScheduledOpt) ([]Result, error) {
+	return ScheduleOn(version, nil, opts...)
+}
+
+// ScheduleOn schedules the opts on a cluster which already runs the installed opts. Installed opts satisfy
+// dependencies without being run, and none of the scheduled opts may conflict with them
+func ScheduleOn(version *semver.Version, installed []string, opts ...ScheduledOpt) ([]Result, error) {
+	unsupported, err := unsupportedOpts(version, opts)
+	if err != nil {
+		return nil, err
+	}
+	supported := []ScheduledOpt{}
+	for _, opt := range opts {
+		if _, skipped := unsupported[opt.Descriptor().Name]; !skipped {
+			supported = append(supported, opt)
+		}
+	}
+	if err := validate(installed, supported); err != nil {
+		return nil, err
+	}
+
+	done := map[string]chan struct{}{}
+	for _, name := range installed {
+		done[name] = make(chan struct{})
+		close(done[name])
+	}
+	for _, opt := range supported {
+		done[opt.Descriptor().Name] = make(chan struct{})
+	}
+
+	results := make([]Result, len(opts))
+	failed := sync.Map{}
+	wg := sync.WaitGroup{}
+	for i, opt := range opts {
+		if reason, skipped := unsupported[opt.Descriptor().Name]; skipped {
+			results[i] = Result{Name: opt.Descriptor().Name, Skipped: true, Reason: reason}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, opt ScheduledOpt) {
+			defer wg.Done()
+			d := opt.Descriptor()
+			defer close(done[d.Name])
+			results[i].Name = d.Name
+
+			for _, dep := range d.Dependencies {
+				<-done[dep]
+				if _, depFailed := failed.Load(dep); depFailed {
+					failed.Store(d.Name, true)
+					results[i].Skipped = true
+					results[i].Reason = fmt.Sprintf("dependency %s failed", dep)
+					results[i].Err = fmt.Errorf("%s skipped, dependency %s failed", d.Name, dep)
+					return
+				}
+			}
+
+			logrus.Infof("Starting %s", d.Name)
+			start := time.Now()
+			err := opt.Exec()
+			results[i].Duration = time.Since(start)
+			if err != nil {
+				failed.Store(d.Name, true)
+				results[i].Err = fmt.Errorf("%s failed: %w", d.Name, err)
+				return
+			}
+			logrus.Infof("Finished %s after %s", d.Name, results[i].Duration.Round(time.Second))
+		}(i, opt)
+	}
+	wg.Wait()
+
+	report(results)
+
+	errs := []error{}
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// unsupportedOpts returns the opts which can not run on the Kubernetes version and why,
+// opts depending on them can not run either
+func unsupportedOpts(version *semver.Version, opts []ScheduledOpt) (map[string]string, error) {
+	unsupported := map[string]string{}
+	for _, opt := range opts {
+		d := opt.Descriptor()
+		if d.KubernetesVersion == "" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(d.KubernetesVersion)
+		if err != nil {
+			return nil, fmt.Errorf("%s has an invalid Kubernetes version constraint %q: %w", d.Name, d.KubernetesVersion, err)
+		}
+		if version != nil && !constraint.Check(version) {
+			unsupported[d.Name] = fmt.Sprintf("it requires Kubernetes %s but the cluster runs %s", d.KubernetesVersion, version)
+		}
+	}
+
+	for changed := len(unsupported) > 0; changed; {
+		changed = false
+		for _, opt := range opts {
+			d := opt.Descriptor()
+			if _, skipped := unsupported[d.Name]; skipped {
+				continue
+			}
+			for _, dep := range d.Dependencies {
+				if _, skipped := unsupported[dep]; skipped {
+					unsupported[d.Name] = fmt.Sprintf("it depends on %s, which is skipped", dep)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, opt := range opts {
+		name := opt.Descriptor().Name
+		if reason, skipped := unsupported[name]; skipped {
+			logrus.Warnf("Skipping %s, %s", name, reason)
+		}
+	}
+	return unsupported, nil
+}
+
+func validate(installed []string, opts []ScheduledOpt) error {
+	scheduled := map[string]Descriptor{}
+	for _, opt := range opts {
+		d := opt.Descriptor()
+		if _, exists := scheduled[d.Name]; exists {
+			return fmt.Errorf("%s is scheduled more than once", d.Name)
+		}
+		scheduled[d.Name] = d
+	}
+	enabled := map[string]bool{}
+	for _, name := range installed {
+		enabled[name] = true
+	}
+	for name := range scheduled {
+		enabled[name] = true
+	}
+
+	for _, d := range scheduled {
+		for _, dep := range d.Dependencies {
+			if !enabled[dep] {
+				return fmt.Errorf("%s depends on %s, which is not enabled", d.Name, dep)
+			}
+		}
+		for _, conflict := range d.Conflicts {
+			if enabled[conflict] && conflict != d.Name {
+				return fmt.Errorf("%s conflicts with %s, only one of them can be enabled", d.Name, conflict)
+			}
+		}
+	}
+
+	return checkCycles(scheduled)
+}
+
+// checkCycles walks the dependency graph depth first, a dependency which is still on the stack closes a cycle
+func checkCycles(scheduled map[string]Descriptor) error {
+	const (
+		unvisited = iota
+		inProgress
+		visited
+	)
+	state := map[string]int{}
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case inProgress:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = inProgress
+		for _, dep := range scheduled[name].Dependencies {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	names := make([]string, 0, len(scheduled))
+	for name := range scheduled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func report(results []Result) {
+	if len(results) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Opt timings:")
+	for _, r := range results {
+		status := "ok"
+		switch {
+		case r.Skipped:
+			status = "skipped"
+		case r.Err != nil:
+			status = "failed"
+		}
+		fmt.Fprintf(&b, "\n  %-30s %-8s %s", r.Name, status, r.Duration.Round(time.Second))
+	}
+	logrus.Info(b.String())
+}
@@ -0,0 +1,146 @@
+package opts
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpts(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Opts Suite")
+}
+
+type fakeOpt struct {
+	descriptor Descriptor
+	exec       func() error
+}
+
+func (o *fakeOpt) Exec() error {
+	if o.exec == nil {
+		return nil
+	}
+	return o.exec()
+}
+
+func (o *fakeOpt) Descriptor() Descriptor {
+	return o.descriptor
+}
+
+var _ = Describe("Schedule", func() {
+	var (
+		lock  sync.Mutex
+		order []string
+	)
+
+	BeforeEach(func() {
+		order = nil
+	})
+
+	newOpt := func(name string, dependencies ...string) *fakeOpt {
+		return &fakeOpt{
+			descriptor: Descriptor{Name: name, Dependencies: dependencies},
+			exec: func() error {
+				lock.Lock()
+				defer lock.Unlock()
+				order = append(order, name)
+				return nil
+			},
+		}
+	}
+
+	It("should run opts after their dependencies", func() {
+		results, err := Schedule(nil, newOpt("istio", "cnao"), newOpt("cnao", "multus"), newOpt("multus"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"multus", "cnao", "istio"}))
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].Name).To(Equal("istio"))
+	})
+
+	It("should run independent opts concurrently", func() {
+		// both opts only return once the other one started as well
+		started := sync.WaitGroup{}
+		started.Add(2)
+		bothStarted := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(bothStarted)
+		}()
+		waitForOther := func() error {
+			started.Done()
+			select {
+			case <-bothStarted:
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("opts did not run concurrently")
+			}
+		}
+		ceph := &fakeOpt{descriptor: Descriptor{Name: "ceph"}, exec: waitForOther}
+		prometheus := &fakeOpt{descriptor: Descriptor{Name: "prometheus"}, exec: waitForOther}
+
+		_, err := Schedule(nil, ceph, prometheus)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should skip opts whose dependencies failed and run all others", func() {
+		multus := newOpt("multus")
+		multus.exec = func() error { return errors.New("rollout timed out") }
+
+		results, err := Schedule(nil, multus, newOpt("cnao", "multus"), newOpt("ceph"))
+		Expect(err).To(MatchError(ContainSubstring("multus failed: rollout timed out")))
+		Expect(err).To(MatchError(ContainSubstring("cnao skipped, dependency multus failed")))
+		Expect(order).To(Equal([]string{"ceph"}))
+		Expect(results[1].Skipped).To(BeTrue())
+	})
+
+	It("should treat installed opts as finished dependencies", func() {
+		_, err := ScheduleOn(nil, []string{"multus"}, newOpt("cnao", "multus"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"cnao"}))
+	})
+
+	It("should refuse opts conflicting with installed opts", func() {
+		_, err := ScheduleOn(nil, []string{"nfs-csi"}, &fakeOpt{descriptor: Descriptor{Name: "ceph", Conflicts: []string{"nfs-csi"}}})
+		Expect(err).To(MatchError("ceph conflicts with nfs-csi, only one of them can be enabled"))
+	})
+
+	It("should skip opts which do not support the Kubernetes version together with their dependents", func() {
+		aaq := newOpt("aaq")
+		aaq.descriptor.KubernetesVersion = ">=1.30"
+
+		results, err := Schedule(semver.MustParse("1.29.4"), aaq, newOpt("quota-tests", "aaq"), newOpt("multus"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"multus"}))
+		Expect(results[0]).To(Equal(Result{Name: "aaq", Skipped: true, Reason: "it requires Kubernetes >=1.30 but the cluster runs 1.29.4"}))
+		Expect(results[1]).To(Equal(Result{Name: "quota-tests", Skipped: true, Reason: "it depends on aaq, which is skipped"}))
+		Expect(results[2].Skipped).To(BeFalse())
+	})
+
+	It("should run opts which support the Kubernetes version", func() {
+		aaq := newOpt("aaq")
+		aaq.descriptor.KubernetesVersion = ">=1.30"
+
+		_, err := Schedule(semver.MustParse("1.31.0"), aaq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"aaq"}))
+	})
+
+	DescribeTable("should refuse to run invalid schedules", func(expected string, opts ...ScheduledOpt) {
+		_, err := Schedule(nil, opts...)
+		Expect(err).To(MatchError(expected))
+		Expect(order).To(BeEmpty())
+	},
+		Entry("missing dependency", "cnao depends on multus, which is not enabled", newOpt("cnao", "multus")),
+		Entry("duplicate", "ceph is scheduled more than once", newOpt("ceph"), newOpt("ceph")),
+		Entry("conflict", "ceph conflicts with nfs-csi, only one of them can be enabled",
+			&fakeOpt{descriptor: Descriptor{Name: "ceph", Conflicts: []string{"nfs-csi"}}}, newOpt("nfs-csi")),
+		Entry("cycle", "dependency cycle: a -> b -> a", newOpt("a", "b"), newOpt("b", "a")),
+		Entry("invalid version constraint", `aaq has an invalid Kubernetes version constraint "newer than 1.30": improper constraint: "newer than 1.30"`,
+			&fakeOpt{descriptor: Descriptor{Name: "aaq", KubernetesVersion: "newer than 1.30"}}),
+	)
+})
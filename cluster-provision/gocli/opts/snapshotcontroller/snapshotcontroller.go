@@ -0,0 +1,61 @@
+package snapshotcontroller
+
+import (
+	"context"
+	"embed"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/*
+var f embed.FS
+
+// Name is the name the storage opts with snapshot support depend on
+const Name = "snapshot-controller"
+
+type snapshotControllerOpt struct {
+	client k8s.K8sDynamicClient
+}
+
+// NewSnapshotControllerOpt creates an opt which deploys the CRDs and the controller of the external-snapshotter,
+// they are shared by all CSI drivers with snapshot support
+func NewSnapshotControllerOpt(c k8s.K8sDynamicClient) *snapshotControllerOpt {
+	return &snapshotControllerOpt{
+		client: c,
+	}
+}
+
+func (o *snapshotControllerOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: Name}
+}
+
+func (o *snapshotControllerOpt) Exec() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	return k8s.WaitForDeploymentRollout(ctx, o.client, "snapshot-controller", "kube-system")
+}
+
+// Uninstall deletes the CRDs together with all snapshots and the controller
+func (o *snapshotControllerOpt) Uninstall() error {
+	bundle, err := common.LoadBundle(f, "manifests")
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *snapshotControllerOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "snapshot-controller", "kube-system", k8s.DeploymentRolledOut)
+}
@@ -0,0 +1,41 @@
+package snapshotcontroller
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestSnapshotControllerOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SnapshotControllerOpt Suite")
+}
+
+var _ = Describe("SnapshotControllerOpt", func() {
+	var k8sClient k8s.K8sDynamicClient
+
+	crdGVK := schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+	BeforeEach(func() {
+		k8sClient = k8s.NewTestClient()
+	})
+
+	It("should deploy and remove the snapshot CRDs and controller", func() {
+		opt := NewSnapshotControllerOpt(k8sClient)
+		Expect(opt.Exec()).To(Succeed())
+
+		_, err := k8sClient.Get(crdGVK, "volumesnapshots.snapshot.storage.k8s.io", "")
+		Expect(err).NotTo(HaveOccurred())
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		_, err = k8sClient.Get(crdGVK, "volumesnapshots.snapshot.storage.k8s.io", "")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
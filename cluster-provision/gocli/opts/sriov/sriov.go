@@ -0,0 +1,69 @@
+package sriov
+
+import (
+	"context"
+	_ "embed"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/sriov.yaml
+var sriov []byte
+
+// ResourceName is the extended resource the VFs of the emulated SR-IOV NICs are advertised as
+const ResourceName = "kubevirt.io/sriov_net"
+
+const namespace = "kube-system"
+
+type sriovOpt struct {
+	client k8s.K8sDynamicClient
+}
+
+// NewSriovOpt creates an opt which deploys the SR-IOV CNI and the SR-IOV device plugin, the device plugin advertises
+// the VFs the VFs opt bound to vfio-pci as ResourceName
+func NewSriovOpt(c k8s.K8sDynamicClient) *sriovOpt {
+	return &sriovOpt{
+		client: c,
+	}
+}
+
+func (o *sriovOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "sriov"}
+}
+
+func (o *sriovOpt) Exec() error {
+	bundle, err := common.ParseBundle(sriov)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	for _, daemonSet := range []string{"kube-sriov-cni-ds", "kube-sriov-device-plugin"} {
+		if err := k8s.WaitForDaemonSetRollout(ctx, o.client, daemonSet, namespace); err != nil {
+			return err
+		}
+	}
+	logrus.Infof("the VFs of the emulated SR-IOV NICs are advertised as %s", ResourceName)
+	return nil
+}
+
+func (o *sriovOpt) Uninstall() error {
+	bundle, err := common.ParseBundle(sriov)
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *sriovOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "kube-sriov-device-plugin", namespace, k8s.DaemonSetRolledOut)
+}
@@ -0,0 +1,73 @@
+package sriov
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func TestSriovOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SriovOpt Suite")
+}
+
+var _ = Describe("SriovOpt", func() {
+	var client k8s.K8sDynamicClient
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	It("should deploy the SR-IOV CNI and device plugin", func() {
+		opt := NewSriovOpt(client)
+		Expect(opt.Exec()).To(Succeed())
+
+		config, err := client.Get(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, "sriovdp-config", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		data, _, err := unstructured.NestedString(config.Object, "data", "config.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(ContainSubstring(`"drivers": ["vfio-pci"]`))
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+
+		Expect(opt.Uninstall()).To(Succeed())
+		status, err = opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Installed).To(BeFalse())
+	})
+})
+
+var _ = Describe("VFsOpt", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		sshClient *kubevirtcimocks.MockSSHClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should create the VFs on the node", func() {
+		AddExpectCalls(sshClient, 3)
+		Expect(NewVFsOpt(sshClient, 3).Exec()).To(Succeed())
+	})
+
+	It("should fail if the VFs can not be created", func() {
+		sshClient.EXPECT().Command(fmt.Sprintf(createVFs, 8)).Return(fmt.Errorf("/sys/bus/pci/drivers/igb/0000:81:00.0 supports 7 VFs"))
+		Expect(NewVFsOpt(sshClient, 8).Exec()).To(MatchError(ContainSubstring("supports 7 VFs")))
+	})
+})
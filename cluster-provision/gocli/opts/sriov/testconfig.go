@@ -0,0 +1,11 @@
+package sriov
+
+import (
+	"fmt"
+
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient, vfs int) {
+	sshClient.EXPECT().Command(fmt.Sprintf(createVFs, vfs))
+}
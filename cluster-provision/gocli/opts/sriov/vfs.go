@@ -0,0 +1,48 @@
+package sriov
+
+import (
+	"fmt"
+
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+)
+
+// DefaultVFs is the number of VFs created on every emulated SR-IOV NIC, it is the most the igb driver supports
+const DefaultVFs = 7
+
+// createVFs creates the VFs on every igb NIC of the node and binds them to vfio-pci. Probing the VFs is disabled
+// before they are created, so they never show up as igbvf interfaces on the node
+const createVFs = `set -e
+modprobe -i vfio-pci
+pfs=$(find /sys/bus/pci/drivers/igb -maxdepth 1 -name '0000:*')
+if [[ -z "$pfs" ]]; then echo 'No emulated SR-IOV NIC found' && exit 1; fi
+for pf in $pfs; do
+  if [[ %[1]d -gt $(cat $pf/sriov_totalvfs) ]]; then echo "$pf supports $(cat $pf/sriov_totalvfs) VFs" && exit 1; fi
+  echo 0 > $pf/sriov_drivers_autoprobe
+  echo 0 > $pf/sriov_numvfs
+  echo %[1]d > $pf/sriov_numvfs
+  ip link set $(ls $pf/net) up
+  for vf in $pf/virtfn*; do
+    echo vfio-pci > $vf/driver_override
+    basename $(readlink -f $vf) > /sys/bus/pci/drivers_probe
+  done
+done`
+
+type vfsOpt struct {
+	sshClient libssh.Client
+	vfs       int
+}
+
+// NewVFsOpt creates an opt which creates the given number of VFs on the emulated SR-IOV NICs of a node
+func NewVFsOpt(sc libssh.Client, vfs int) *vfsOpt {
+	return &vfsOpt{
+		sshClient: sc,
+		vfs:       vfs,
+	}
+}
+
+func (o *vfsOpt) Exec() error {
+	if err := o.sshClient.Command(fmt.Sprintf(createVFs, o.vfs)); err != nil {
+		return fmt.Errorf("error creating the VFs of the emulated SR-IOV NICs: %w", err)
+	}
+	return nil
+}
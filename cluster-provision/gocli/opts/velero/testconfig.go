@@ -0,0 +1,28 @@
+package velero
+
+import (
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func AddExpectCalls(sshClient *kubevirtcimocks.MockSSHClient, inClusterMinIO, kubevirtPlugin bool) {
+	s3Url := "http://192.168.66.2:9000"
+	if inClusterMinIO {
+		s3Url = "http://minio.minio.svc:9000"
+	}
+	plugins := "velero/velero-plugin-for-aws:v1.10.1"
+	if kubevirtPlugin {
+		plugins += ",quay.io/kubevirt/kubevirt-velero-plugin:v0.7.0"
+	}
+
+	cmds := []string{
+		"echo '[default]\naws_access_key_id=minio\naws_secret_access_key=minio123\n' | tee /opt/velero-credentials > /dev/null",
+		"source /var/lib/kubevirtci/shared_vars.sh && PATH=$VELERO_BIN_DIR:$PATH velero --kubeconfig /etc/kubernetes/admin.conf install" +
+			" --provider aws --plugins " + plugins + " --bucket velero --secret-file /opt/velero-credentials" +
+			" --backup-location-config region=minio,s3ForcePathStyle=true,s3Url=" + s3Url +
+			" --features=EnableCSI --use-volume-snapshots=false --wait",
+	}
+
+	for _, cmd := range cmds {
+		sshClient.EXPECT().Command(cmd)
+	}
+}
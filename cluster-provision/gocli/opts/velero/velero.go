@@ -0,0 +1,182 @@
+package velero
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/snapshotcontroller"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
+)
+
+//go:embed manifests/minio.yaml
+var minio []byte
+
+//go:embed manifests/bucket.yaml
+var bucket []byte
+
+const (
+	// MinIOUser and MinIOPassword are the credentials of MinIO, the manifests use them as well
+	MinIOUser     = "minio"
+	MinIOPassword = "minio123"
+	// Bucket keeps the backups
+	Bucket = "velero"
+	// SidecarEndpoint is the API of the minio container, which runs in the network namespace of dnsmasq
+	SidecarEndpoint = "http://192.168.66.2:9000"
+	// ConsoleNodePort publishes the console of the in-cluster MinIO
+	ConsoleNodePort = 30009
+	// MinIOSidecar runs MinIO in a container next to the nodes, MinIOInCluster deploys it in the cluster
+	MinIOSidecar   = "sidecar"
+	MinIOInCluster = "in-cluster"
+
+	inClusterEndpoint = "http://minio.minio.svc:9000"
+	namespace         = "velero"
+	credentialsFile   = "/opt/velero-credentials"
+
+	awsPlugin      = "velero/velero-plugin-for-aws:v1.10.1"
+	kubevirtPlugin = "quay.io/kubevirt/kubevirt-velero-plugin:v0.7.0"
+)
+
+type veleroOpt struct {
+	client         k8s.K8sDynamicClient
+	sshClient      libssh.Client
+	inClusterMinIO bool
+	kubevirtPlugin bool
+}
+
+// NewVeleroOpt creates an opt which installs Velero with its CSI support and a backup storage location in MinIO.
+// With inClusterMinIO MinIO is deployed in the cluster, otherwise the minio container next to the nodes is used,
+// kubevirtPlugin adds the KubeVirt Velero plugin
+func NewVeleroOpt(c k8s.K8sDynamicClient, sc libssh.Client, inClusterMinIO, kubevirtPlugin bool) *veleroOpt {
+	return &veleroOpt{
+		client:         c,
+		sshClient:      sc,
+		inClusterMinIO: inClusterMinIO,
+		kubevirtPlugin: kubevirtPlugin,
+	}
+}
+
+func (o *veleroOpt) Descriptor() opts.Descriptor {
+	return opts.Descriptor{Name: "velero", Dependencies: []string{snapshotcontroller.Name}}
+}
+
+func (o *veleroOpt) Exec() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	endpoint := SidecarEndpoint
+	if o.inClusterMinIO {
+		endpoint = inClusterEndpoint
+		bundle, err := common.ParseBundle(minio)
+		if err != nil {
+			return err
+		}
+		if err := bundle.Apply(o.client); err != nil {
+			return err
+		}
+		if err := k8s.WaitForDeploymentRollout(ctx, o.client, "minio", "minio"); err != nil {
+			return err
+		}
+	}
+
+	bundle, err := common.ParseBundle(bucket)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Mutate(withEndpoint(endpoint)); err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+	if err := k8s.WaitForCondition(ctx, o.client, batchv1.SchemeGroupVersion.WithKind("Job"), "minio-create-bucket", namespace, "Complete"); err != nil {
+		return err
+	}
+
+	for _, cmd := range o.installCommands(endpoint) {
+		if err := o.sshClient.Command(cmd); err != nil {
+			return err
+		}
+	}
+
+	logrus.Info("Velero is now ready!")
+	return nil
+}
+
+// Uninstall removes Velero with its CRDs and namespace, and the in-cluster MinIO if there is one
+func (o *veleroOpt) Uninstall() error {
+	veleroUninstallCmd := "source /var/lib/kubevirtci/shared_vars.sh && PATH=$VELERO_BIN_DIR:$PATH velero --kubeconfig /etc/kubernetes/admin.conf uninstall --force"
+	if err := o.sshClient.Command(veleroUninstallCmd); err != nil {
+		return err
+	}
+
+	bundle, err := common.ParseBundle(minio, bucket)
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *veleroOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("Deployment"), "velero", namespace, k8s.DeploymentRolledOut)
+}
+
+// installCommands writes the MinIO credentials to node01 and installs Velero with the velero CLI of the provider,
+// it waits for Velero to be ready
+func (o *veleroOpt) installCommands(endpoint string) []string {
+	plugins := []string{awsPlugin}
+	if o.kubevirtPlugin {
+		plugins = append(plugins, kubevirtPlugin)
+	}
+
+	credentials := fmt.Sprintf("[default]\naws_access_key_id=%s\naws_secret_access_key=%s\n", MinIOUser, MinIOPassword)
+	return []string{
+		fmt.Sprintf("echo '%s' | tee %s > /dev/null", credentials, credentialsFile),
+		"source /var/lib/kubevirtci/shared_vars.sh && PATH=$VELERO_BIN_DIR:$PATH velero --kubeconfig /etc/kubernetes/admin.conf install" +
+			" --provider aws" +
+			" --plugins " + strings.Join(plugins, ",") +
+			" --bucket " + Bucket +
+			" --secret-file " + credentialsFile +
+			" --backup-location-config region=minio,s3ForcePathStyle=true,s3Url=" + endpoint +
+			" --features=EnableCSI" +
+			" --use-volume-snapshots=false" +
+			" --wait",
+	}
+}
+
+// withEndpoint points the bucket job to the MinIO the cluster uses
+func withEndpoint(endpoint string) common.Mutator {
+	return func(obj *unstructured.Unstructured) error {
+		if obj.GetKind() != "Job" {
+			return nil
+		}
+		containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		if err != nil {
+			return err
+		}
+		for _, container := range containers {
+			env, _, err := unstructured.NestedSlice(container.(map[string]interface{}), "env")
+			if err != nil {
+				return err
+			}
+			for _, variable := range env {
+				if variable.(map[string]interface{})["name"] == "MINIO_ENDPOINT" {
+					variable.(map[string]interface{})["value"] = endpoint
+				}
+			}
+			if err := unstructured.SetNestedSlice(container.(map[string]interface{}), env, "env"); err != nil {
+				return err
+			}
+		}
+		return unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+	}
+}
@@ -0,0 +1,65 @@
+package velero
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+	kubevirtcimocks "kubevirt.io/kubevirtci/cluster-provision/gocli/utils/mock"
+)
+
+func TestVeleroOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "VeleroOpt Suite")
+}
+
+var _ = Describe("VeleroOpt", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		sshClient *kubevirtcimocks.MockSSHClient
+		k8sClient k8s.K8sDynamicClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		sshClient = kubevirtcimocks.NewMockSSHClient(mockCtrl)
+		k8sClient = k8s.NewTestClient(k8s.NewReactorConfig("create", "jobs", k8s.ConditionReactor("Complete")))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	bucketEndpoint := func() string {
+		job, err := k8sClient.Get(batchv1.SchemeGroupVersion.WithKind("Job"), "minio-create-bucket", namespace)
+		Expect(err).NotTo(HaveOccurred())
+		containers, _, err := unstructured.NestedSlice(job.Object, "spec", "template", "spec", "containers")
+		Expect(err).NotTo(HaveOccurred())
+		env, _, err := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env")
+		Expect(err).NotTo(HaveOccurred())
+		return env[0].(map[string]interface{})["value"].(string)
+	}
+
+	It("should install Velero with the minio container", func() {
+		AddExpectCalls(sshClient, false, false)
+		Expect(NewVeleroOpt(k8sClient, sshClient, false, false).Exec()).To(Succeed())
+
+		Expect(bucketEndpoint()).To(Equal(SidecarEndpoint))
+		_, err := k8sClient.Get(appsv1.SchemeGroupVersion.WithKind("Deployment"), "minio", "minio")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should install Velero with MinIO in the cluster and the KubeVirt plugin", func() {
+		AddExpectCalls(sshClient, true, true)
+		Expect(NewVeleroOpt(k8sClient, sshClient, true, true).Exec()).To(Succeed())
+
+		Expect(bucketEndpoint()).To(Equal(inClusterEndpoint))
+		_, err := k8sClient.Get(appsv1.SchemeGroupVersion.WithKind("Deployment"), "minio", "minio")
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
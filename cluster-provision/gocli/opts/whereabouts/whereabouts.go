@@ -0,0 +1,127 @@
+package whereabouts
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts"
+	"kubevirt.io/kubevirtci/cluster-provision/gocli/opts/common"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+//go:embed manifests/whereabouts.yaml
+var whereabouts []byte
+
+//go:embed manifests/network.yaml
+var network []byte
+
+type whereaboutsOpt struct {
+	client            k8s.K8sDynamicClient
+	multusEnabled     bool
+	secondaryNetworks uint
+}
+
+// NewWhereaboutsOpt creates an opt which deploys the whereabouts IPAM plugin. With secondaryNetworks, a
+// NetworkAttachmentDefinition is created in the default namespace for the bridges br1 to brN of the secondary nics of
+// the nodes, each with its own range. The NetworkAttachmentDefinitions need the multus opt to be enabled
+func NewWhereaboutsOpt(c k8s.K8sDynamicClient, multusEnabled bool, secondaryNetworks uint) *whereaboutsOpt {
+	return &whereaboutsOpt{
+		client:            c,
+		multusEnabled:     multusEnabled,
+		secondaryNetworks: secondaryNetworks,
+	}
+}
+
+func (o *whereaboutsOpt) Descriptor() opts.Descriptor {
+	deps := []string{}
+	if o.multusEnabled {
+		deps = append(deps, "multus")
+	}
+	return opts.Descriptor{Name: "whereabouts", Dependencies: deps}
+}
+
+func (o *whereaboutsOpt) Exec() error {
+	if o.secondaryNetworks > 0 && !o.multusEnabled {
+		return fmt.Errorf("the networks of the secondary nics need multus to be enabled")
+	}
+
+	bundle, err := common.ParseBundle(whereabouts)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Apply(o.client); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Second)
+	defer cancel()
+	if err := k8s.WaitForDaemonSetRollout(ctx, o.client, "whereabouts", "kube-system"); err != nil {
+		return err
+	}
+
+	networks, err := o.networks()
+	if err != nil {
+		return err
+	}
+	return networks.Apply(o.client)
+}
+
+func (o *whereaboutsOpt) Uninstall() error {
+	networks, err := o.networks()
+	if err != nil {
+		return err
+	}
+	if err := networks.Delete(o.client); err != nil {
+		return err
+	}
+
+	bundle, err := common.ParseBundle(whereabouts)
+	if err != nil {
+		return err
+	}
+	return bundle.Delete(o.client)
+}
+
+func (o *whereaboutsOpt) Status() (opts.Status, error) {
+	return common.CheckStatus(o.client, appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "whereabouts", "kube-system", k8s.DaemonSetRolledOut)
+}
+
+// networks returns a NetworkAttachmentDefinition named whereabouts-brN for each secondary nic, the range of brN is
+// 10.200.N.0/24, which overlaps neither with the node network nor with the pod and service networks
+func (o *whereaboutsOpt) networks() (*common.Bundle, error) {
+	manifests := [][]byte{}
+	for i := uint(0); i < o.secondaryNetworks; i++ {
+		manifests = append(manifests, network)
+	}
+	bundle, err := common.ParseBundle(manifests...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, obj := range bundle.Objects() {
+		bridge := fmt.Sprintf("br%d", i+1)
+		config, err := json.Marshal(map[string]interface{}{
+			"cniVersion": "0.3.1",
+			"name":       "whereabouts-" + bridge,
+			"type":       "bridge",
+			"bridge":     bridge,
+			"ipam": map[string]interface{}{
+				"type":  "whereabouts",
+				"range": fmt.Sprintf("10.200.%d.0/24", i+1),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		obj.SetName("whereabouts-" + bridge)
+		if err := unstructured.SetNestedField(obj.Object, string(config), "spec", "config"); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
@@ -0,0 +1,61 @@
+package whereabouts
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/k8s"
+)
+
+func TestWhereaboutsOpt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WhereaboutsOpt Suite")
+}
+
+var _ = Describe("WhereaboutsOpt", func() {
+	var client k8s.K8sDynamicClient
+	nadGVK := schema.GroupVersionKind{Group: "k8s.cni.cncf.io", Version: "v1", Kind: "NetworkAttachmentDefinition"}
+
+	BeforeEach(func() {
+		client = k8s.NewTestClient()
+	})
+
+	It("should deploy whereabouts", func() {
+		opt := NewWhereaboutsOpt(client, false, 0)
+		Expect(opt.Descriptor().Dependencies).To(BeEmpty())
+		Expect(opt.Exec()).To(Succeed())
+
+		status, err := opt.Status()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Ready).To(BeTrue())
+	})
+
+	It("should create a network with its own range for each secondary nic", func() {
+		opt := NewWhereaboutsOpt(client, true, 2)
+		Expect(opt.Descriptor().Dependencies).To(ConsistOf("multus"))
+		Expect(opt.Exec()).To(Succeed())
+
+		for nic, config := range map[string]string{
+			"br1": `{"bridge":"br1","cniVersion":"0.3.1","ipam":{"range":"10.200.1.0/24","type":"whereabouts"},"name":"whereabouts-br1","type":"bridge"}`,
+			"br2": `{"bridge":"br2","cniVersion":"0.3.1","ipam":{"range":"10.200.2.0/24","type":"whereabouts"},"name":"whereabouts-br2","type":"bridge"}`,
+		} {
+			nad, err := client.Get(nadGVK, "whereabouts-"+nic, "default")
+			Expect(err).NotTo(HaveOccurred())
+			actual, _, err := unstructured.NestedString(nad.Object, "spec", "config")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actual).To(Equal(config))
+		}
+
+		Expect(opt.Uninstall()).To(Succeed())
+		_, err := client.Get(nadGVK, "whereabouts-br1", "default")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should refuse to create networks without multus", func() {
+		opt := NewWhereaboutsOpt(client, false, 1)
+		Expect(opt.Exec()).To(MatchError(ContainSubstring("need multus to be enabled")))
+	})
+})
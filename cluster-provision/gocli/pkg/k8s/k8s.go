@@ -2,8 +2,8 @@ package utils
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
-	"strings"
 	"time"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
@@ -19,16 +19,21 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/yaml"
@@ -36,6 +41,9 @@ import (
 
 var s = initSchema()
 
+// fieldManager identifies gocli as the owner of the fields it applies
+const fieldManager = "gocli"
+
 type K8sDynamicClient interface {
 	Get(gvk schema.GroupVersionKind, name, ns string) (*unstructured.Unstructured, error)
 	Apply(obj *unstructured.Unstructured) error
@@ -45,8 +53,10 @@ type K8sDynamicClient interface {
 }
 
 type k8sDynamicClientImpl struct {
-	scheme *runtime.Scheme
-	client dynamic.Interface
+	scheme     *runtime.Scheme
+	client     dynamic.Interface
+	mapper     meta.ResettableRESTMapper
+	newBackOff func() backoff.BackOff
 }
 type ReactorConfig struct {
 	verb      string
@@ -70,21 +80,70 @@ func NewDynamicClient(config *rest.Config) (*k8sDynamicClientImpl, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error creating dynamic client: %v", err)
 	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating discovery client: %v", err)
+	}
+	return newDynamicClient(dynamicClient, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))), nil
+}
+
+func newDynamicClient(client dynamic.Interface, mapper meta.ResettableRESTMapper) *k8sDynamicClientImpl {
 	return &k8sDynamicClientImpl{
-		client: dynamicClient,
-		scheme: s,
-	}, nil
+		client:     client,
+		scheme:     s,
+		mapper:     mapper,
+		newBackOff: newApplyBackOff,
+	}
 }
 
 func NewTestClient(reactors ...ReactorConfig) *k8sDynamicClientImpl {
+	reactors = append(reactors, readyReactors...)
 	dynamicClient := fake.NewSimpleDynamicClient(s)
 	for _, r := range reactors {
 		dynamicClient.PrependReactor(r.verb, r.resource, r.reactfunc)
 	}
+	dynamicClient.PrependReactor("patch", "*", applyReactor(dynamicClient.Tracker(), reactors))
 
-	return &k8sDynamicClientImpl{
-		client: dynamicClient,
-		scheme: s,
+	return newDynamicClient(dynamicClient, newGuessingRESTMapper())
+}
+
+// applyReactor emulates server-side apply on top of the fake object tracker, which is only able to patch existing objects.
+// Objects that do not exist yet are passed through the create reactors before they are stored, so tests can keep
+// faking a status on creation
+func applyReactor(tracker testing.ObjectTracker, reactors []ReactorConfig) testing.ReactionFunc {
+	return func(action testing.Action) (bool, runtime.Object, error) {
+		patchAction, ok := action.(testing.PatchAction)
+		if !ok || patchAction.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patchAction.GetPatch()); err != nil {
+			return true, nil, err
+		}
+
+		gvr, ns := action.GetResource(), action.GetNamespace()
+		existing, err := tracker.Get(gvr, ns, patchAction.GetName())
+		switch {
+		case err == nil:
+			if status, found, _ := unstructured.NestedFieldCopy(existing.(*unstructured.Unstructured).Object, "status"); found {
+				obj.Object["status"] = status
+			}
+			return true, obj, tracker.Update(gvr, obj, ns)
+		case !errors.IsNotFound(err):
+			return true, nil, err
+		}
+
+		createAction := testing.NewCreateAction(gvr, ns, obj)
+		for _, r := range reactors {
+			if r.verb != "create" || (r.resource != "*" && r.resource != gvr.Resource) {
+				continue
+			}
+			if handled, ret, err := r.reactfunc(createAction); handled {
+				return handled, ret, err
+			}
+		}
+		return true, obj, tracker.Create(gvr, obj, ns)
 	}
 }
 
@@ -111,16 +170,7 @@ func (c *k8sDynamicClientImpl) Get(gvk schema.GroupVersionKind, name, ns string)
 }
 
 func (c *k8sDynamicClientImpl) Update(newResource *unstructured.Unstructured) error {
-	gv := strings.Split(newResource.GetAPIVersion(), "/")
-	if len(gv) != 2 {
-		return fmt.Errorf("resource has no proper group and version, got: %s", newResource.GetAPIVersion())
-	}
-
-	resourceClient, err := c.initResourceClientForGVKAndNamespace(schema.GroupVersionKind{
-		Group:   gv[0],
-		Version: gv[1],
-		Kind:    newResource.GetKind(),
-	}, newResource.GetNamespace())
+	resourceClient, err := c.initResourceClientForGVKAndNamespace(newResource.GroupVersionKind(), newResource.GetNamespace())
 	if err != nil {
 		return err
 	}
@@ -146,15 +196,14 @@ func (c *k8sDynamicClientImpl) List(gvk schema.GroupVersionKind, ns string) (*un
 }
 
 func (c *k8sDynamicClientImpl) Apply(obj *unstructured.Unstructured) error {
-	gvk := obj.GroupVersionKind()
-	resourceClient, err := c.initResourceClientForGVKAndNamespace(gvk, obj.GetNamespace())
+	err := c.applyWithExponentialBackoff(obj)
 	if err != nil {
 		return err
 	}
 
-	err = c.createWithExponentialBackoff(resourceClient, obj)
-	if err != nil {
-		return err
+	if obj.GroupVersionKind().GroupKind() == crdGVK.GroupKind() {
+		// make the kinds of the new CRD resolvable for the following objects
+		c.mapper.Reset()
 	}
 	logrus.Infof("Object %v applied successfully", obj.GetName())
 	return nil
@@ -175,21 +224,71 @@ func SerializeIntoObject(manifest []byte) (*unstructured.Unstructured, error) {
 	return obj, nil
 }
 
-func (c *k8sDynamicClientImpl) createWithExponentialBackoff(resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+// applyWithExponentialBackoff server-side applies the object, taking over fields owned by other managers.
+// Only errors which can go away on their own, like webhooks which are not serving yet or CRDs which are not
+// established yet, are retried
+func (c *k8sDynamicClientImpl) applyWithExponentialBackoff(obj *unstructured.Unstructured) error {
 	operation := func() error {
-		_, err := resourceClient.Create(context.TODO(), obj, v1.CreateOptions{})
+		mapping, err := c.restMapping(obj.GroupVersionKind())
+		if err != nil {
+			if !isRetryable(err) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+
+		applied := obj
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot && obj.GetNamespace() != "" {
+			logrus.Debugf("Ignoring namespace %s of cluster scoped %s %s", obj.GetNamespace(), obj.GetKind(), obj.GetName())
+			applied = obj.DeepCopy()
+			applied.SetNamespace("")
+		}
+
+		_, err = resourceClientForMapping(c.client, mapping, applied.GetNamespace()).Apply(context.TODO(), applied.GetName(), applied, v1.ApplyOptions{
+			FieldManager: fieldManager,
+			Force:        true,
+		})
+		if err != nil && !isRetryable(err) {
+			return backoff.Permanent(err)
+		}
 		return err
 	}
 
+	err := backoff.Retry(operation, c.newBackOff())
+	if err != nil {
+		return fmt.Errorf("error applying %s %s: %w", obj.GetKind(), objectKey(obj), err)
+	}
+	return nil
+}
+
+func objectKey(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+func newApplyBackOff() backoff.BackOff {
 	backoffStrategy := backoff.NewExponentialBackOff()
 	backoffStrategy.InitialInterval = 3 * time.Second
 	backoffStrategy.MaxElapsedTime = 1 * time.Minute
+	return backoffStrategy
+}
 
-	err := backoff.Retry(operation, backoffStrategy)
-	if err != nil {
-		return err
+func isRetryable(err error) bool {
+	switch {
+	case errors.IsInternalError(err), errors.IsServiceUnavailable(err), errors.IsTimeout(err),
+		errors.IsServerTimeout(err), errors.IsTooManyRequests(err):
+		// failing admission webhooks are reported as internal errors until their backend is up
+		return true
+	case errors.IsNotFound(err), meta.IsNoMatchError(err):
+		// the resource of a CRD which was just created is not served until the CRD is established
+		return true
 	}
-	return nil
+
+	var status errors.APIStatus
+	// anything that is not an API status, e.g. a refused connection, did not reach the API server
+	return !goerrors.As(err, &status)
 }
 
 func (c *k8sDynamicClientImpl) Delete(gvk schema.GroupVersionKind, name, ns string) error {
@@ -223,15 +322,27 @@ func initSchema() *runtime.Scheme {
 }
 
 func (c *k8sDynamicClientImpl) initResourceClientForGVKAndNamespace(gvk schema.GroupVersionKind, ns string) (dynamic.ResourceInterface, error) {
-	restMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
-	restMapper.Add(gvk, meta.RESTScopeNamespace)
-	mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	mapping, err := c.restMapping(gvk)
 	if err != nil {
 		return nil, err
 	}
-	resourceClient := c.client.Resource(mapping.Resource).Namespace(ns)
-	if ns == "" {
-		resourceClient = c.client.Resource(mapping.Resource)
+	return resourceClientForMapping(c.client, mapping, ns), nil
+}
+
+// restMapping resolves the resource and scope of a kind through discovery
+func (c *k8sDynamicClientImpl) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// the kind may have been added by a CRD after discovery was cached
+		c.mapper.Reset()
+		mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	return mapping, err
+}
+
+func resourceClientForMapping(client dynamic.Interface, mapping *meta.RESTMapping, ns string) dynamic.ResourceInterface {
+	if ns == "" || mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return client.Resource(mapping.Resource)
 	}
-	return resourceClient, nil
+	return client.Resource(mapping.Resource).Namespace(ns)
 }
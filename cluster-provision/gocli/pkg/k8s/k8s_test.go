@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cenkalti/backoff/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestK8s(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "K8s Suite")
+}
+
+var configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+
+func newConfigMap(data string) *unstructured.Unstructured {
+	obj, err := SerializeIntoObject([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+data:
+  key: ` + data + `
+`))
+	Expect(err).NotTo(HaveOccurred())
+	return obj
+}
+
+var _ = Describe("K8sDynamicClient", func() {
+	Describe("Apply", func() {
+		var (
+			client *k8sDynamicClientImpl
+			calls  int
+		)
+
+		BeforeEach(func() {
+			calls = 0
+			client = NewTestClient(NewReactorConfig("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+				Expect(unstructured.SetNestedField(obj.Object, "created", "status", "phase")).To(Succeed())
+				return false, obj, nil
+			}))
+			client.newBackOff = func() backoff.BackOff {
+				return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 5)
+			}
+		})
+
+		failApplyWith := func(errs ...error) {
+			client.client.(*fake.FakeDynamicClient).PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				calls++
+				if calls > len(errs) {
+					return false, nil, nil
+				}
+				return true, nil, errs[calls-1]
+			})
+		}
+
+		It("should create objects which do not exist yet", func() {
+			Expect(client.Apply(newConfigMap("first"))).To(Succeed())
+
+			obj, err := client.Get(configMapGVK, "test", "default")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.Object["data"]).To(HaveKeyWithValue("key", "first"))
+			Expect(obj.Object["status"]).To(HaveKeyWithValue("phase", "created"))
+		})
+
+		It("should update objects which already exist", func() {
+			Expect(client.Apply(newConfigMap("first"))).To(Succeed())
+			Expect(client.Apply(newConfigMap("second"))).To(Succeed())
+
+			obj, err := client.Get(configMapGVK, "test", "default")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.Object["data"]).To(HaveKeyWithValue("key", "second"))
+			Expect(obj.Object["status"]).To(HaveKeyWithValue("phase", "created"))
+		})
+
+		It("should retry errors which can go away on their own", func() {
+			failApplyWith(
+				errors.NewInternalError(errFailedCallingWebhook),
+				errors.NewServiceUnavailable("etcd is not ready"),
+			)
+
+			Expect(client.Apply(newConfigMap("first"))).To(Succeed())
+			Expect(calls).To(Equal(3))
+		})
+
+		It("should not retry permanent errors", func() {
+			failApplyWith(errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "test", errForbidden))
+
+			err := client.Apply(newConfigMap("first"))
+			Expect(errors.IsForbidden(err)).To(BeTrue())
+			Expect(err.Error()).To(HavePrefix("error applying ConfigMap default/test: "))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Describe("REST mapping", func() {
+		var (
+			client    *k8sDynamicClientImpl
+			discovery *fakediscovery.FakeDiscovery
+		)
+
+		widgetGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgetz"}
+
+		BeforeEach(func() {
+			discovery = &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{
+				Resources: []*metav1.APIResourceList{
+					{
+						GroupVersion: "v1",
+						APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}},
+					},
+					{
+						GroupVersion: "apiextensions.k8s.io/v1",
+						APIResources: []metav1.APIResource{{Name: "customresourcedefinitions", Kind: "CustomResourceDefinition"}},
+					},
+				},
+			}}
+
+			dynamicClient := fake.NewSimpleDynamicClient(s)
+			dynamicClient.PrependReactor("patch", "*", applyReactor(dynamicClient.Tracker(), []ReactorConfig{
+				// the api server starts serving the new kind once the CRD is created
+				NewReactorConfig("create", "customresourcedefinitions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					discovery.Resources = append(discovery.Resources, &metav1.APIResourceList{
+						GroupVersion: "example.com/v1",
+						APIResources: []metav1.APIResource{{Name: "widgetz", Kind: "Widget"}},
+					})
+					return false, nil, nil
+				}),
+			}))
+			client = newDynamicClient(dynamicClient, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discovery)))
+			client.newBackOff = func() backoff.BackOff {
+				return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 1)
+			}
+		})
+
+		It("should resolve kinds which are served after their CRD was applied", func() {
+			// warm up the discovery cache before the CRD exists
+			Expect(client.Apply(newConfigMap("first"))).To(Succeed())
+
+			crd, err := SerializeIntoObject([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgetz.example.com
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Apply(crd)).To(Succeed())
+
+			widget, err := SerializeIntoObject([]byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+  namespace: stray
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.Apply(widget)).To(Succeed())
+
+			By("storing the cluster scoped object under its served plural without a namespace")
+			_, err = client.client.Resource(widgetGVR).Get(context.TODO(), "test", metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should fail for kinds which are not served", func() {
+			widget, err := SerializeIntoObject([]byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = client.Apply(widget)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no matches for kind \"Widget\""))
+		})
+	})
+})
+
+var (
+	errFailedCallingWebhook = fmt.Errorf(`failed calling webhook "validate.cdi.kubevirt.io": connection refused`)
+	errForbidden            = fmt.Errorf("not allowed")
+)
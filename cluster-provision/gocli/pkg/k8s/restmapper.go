@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// guessingRESTMapper is used by the test client, the fake dynamic client has no discovery to build a real mapper from.
+// Unknown kinds are registered on first use as namespaced resources with a guessed plural
+type guessingRESTMapper struct {
+	*meta.DefaultRESTMapper
+	lock sync.Mutex
+}
+
+func newGuessingRESTMapper() *guessingRESTMapper {
+	return &guessingRESTMapper{
+		DefaultRESTMapper: meta.NewDefaultRESTMapper(nil),
+	}
+}
+
+func (m *guessingRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mapping, err := m.DefaultRESTMapper.RESTMapping(gk, versions...)
+	if meta.IsNoMatchError(err) && len(versions) > 0 {
+		m.Add(gk.WithVersion(versions[0]), meta.RESTScopeNamespace)
+		return m.DefaultRESTMapper.RESTMapping(gk, versions...)
+	}
+	return mapping, err
+}
+
+func (m *guessingRESTMapper) Reset() {}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/testing"
+)
+
+// readyReactors are added to every test client, they mark workloads and CRDs as ready as soon as they are created
+// so opts waiting for them do not block. Reactors passed to NewTestClient run first, a reactor which handles
+// the create itself keeps the object from being marked as ready
+var readyReactors = []ReactorConfig{
+	NewReactorConfig("create", "deployments", setStatusOnCreate(func(obj *unstructured.Unstructured) map[string]interface{} {
+		replicas := specReplicas(obj)
+		return map[string]interface{}{
+			"replicas":          replicas,
+			"updatedReplicas":   replicas,
+			"readyReplicas":     replicas,
+			"availableReplicas": replicas,
+		}
+	})),
+	NewReactorConfig("create", "daemonsets", setStatusOnCreate(func(obj *unstructured.Unstructured) map[string]interface{} {
+		return map[string]interface{}{
+			"desiredNumberScheduled": int64(1),
+			"currentNumberScheduled": int64(1),
+			"updatedNumberScheduled": int64(1),
+			"numberReady":            int64(1),
+			"numberAvailable":        int64(1),
+		}
+	})),
+	NewReactorConfig("create", "statefulsets", setStatusOnCreate(func(obj *unstructured.Unstructured) map[string]interface{} {
+		replicas := specReplicas(obj)
+		return map[string]interface{}{
+			"replicas":        replicas,
+			"readyReplicas":   replicas,
+			"updatedReplicas": replicas,
+		}
+	})),
+	NewReactorConfig("create", "customresourcedefinitions", setStatusOnCreate(func(obj *unstructured.Unstructured) map[string]interface{} {
+		return map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Established", "status": "True"},
+			},
+		}
+	})),
+}
+
+// ConditionReactor sets a True condition of the given type on the objects it handles as soon as they are created,
+// for opts waiting for a condition of custom resources, nodes or jobs
+func ConditionReactor(conditionType string) func(action testing.Action) (bool, runtime.Object, error) {
+	return setStatusOnCreate(func(obj *unstructured.Unstructured) map[string]interface{} {
+		return map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": conditionType, "status": "True"},
+			},
+		}
+	})
+}
+
+func setStatusOnCreate(status func(obj *unstructured.Unstructured) map[string]interface{}) func(action testing.Action) (bool, runtime.Object, error) {
+	return func(action testing.Action) (bool, runtime.Object, error) {
+		obj := action.(testing.CreateAction).GetObject().(*unstructured.Unstructured)
+		for field, value := range status(obj) {
+			if err := unstructured.SetNestedField(obj.Object, value, "status", field); err != nil {
+				return true, nil, err
+			}
+		}
+		return false, obj, nil
+	}
+}
+
+func specReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found || err != nil {
+		return 1
+	}
+	return replicas
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// VersionLabel is the label of provider images which holds the Kubernetes version they ship, it is set on provision
+	VersionLabel = "io.kubevirtci.k8s-version"
+	// ProviderEnv names the provider in CI jobs whose cluster names contain no version
+	ProviderEnv = "KUBEVIRT_PROVIDER"
+)
+
+var providerVersionRegex = regexp.MustCompile(`.*([0-9]+\.[0-9]+)`)
+
+// ServerVersion asks the API server which Kubernetes version it runs
+func ServerVersion(config *rest.Config) (*semver.Version, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the server version: %w", err)
+	}
+	return ParseVersion(info.GitVersion)
+}
+
+// ProviderVersion returns the Kubernetes version of a provider image from its labels. Images which were provisioned
+// before the label was introduced fall back to the minor version in the provider name, e.g. k8s-1.30, and then to
+// the one in the KUBEVIRT_PROVIDER environment variable
+func ProviderVersion(labels map[string]string, provider string) (*semver.Version, error) {
+	if v, exists := labels[VersionLabel]; exists {
+		return ParseVersion(v)
+	}
+
+	submatches := providerVersionRegex.FindStringSubmatch(provider)
+	if len(submatches) != 2 {
+		kubevirtProvider, defined := os.LookupEnv(ProviderEnv)
+		if !defined {
+			return nil, fmt.Errorf("provider image has no %s label, %q contains no Kubernetes version and %s is not defined", VersionLabel, provider, ProviderEnv)
+		}
+		submatches = providerVersionRegex.FindStringSubmatch(kubevirtProvider)
+		if len(submatches) != 2 {
+			return nil, fmt.Errorf("provider image has no %s label and neither %q nor %s contain a Kubernetes version", VersionLabel, provider, ProviderEnv)
+		}
+	}
+	return ParseVersion(submatches[1])
+}
+
+// ParseVersion parses versions like v1.30.2 or 1.31.0-rc.1. Pre-releases and build metadata are dropped,
+// so version constraints of opts match release candidates of that version as well
+func ParseVersion(v string) (*semver.Version, error) {
+	version, err := semver.NewVersion(v)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid Kubernetes version: %w", v, err)
+	}
+	return semver.New(version.Major(), version.Minor(), version.Patch(), "", ""), nil
+}
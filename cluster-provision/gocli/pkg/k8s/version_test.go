@@ -0,0 +1,31 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	DescribeTable("should take the version of the provider from the image label or its name", func(labels map[string]string, provider, expected string) {
+		version, err := ProviderVersion(labels, provider)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version.String()).To(Equal(expected))
+	},
+		Entry("label", map[string]string{VersionLabel: "1.34.10"}, "k8s-1.33", "1.34.10"),
+		Entry("provider name", nil, "k8s-1.33", "1.33.0"),
+		Entry("provider name with suffix", nil, "k8s-1.35-centos9", "1.35.0"),
+		Entry("provider name without k8s prefix", nil, "kind-1.34", "1.34.0"),
+	)
+
+	It("should fail if neither the label nor the provider name hold a version", func() {
+		GinkgoT().Setenv(ProviderEnv, "custom")
+		_, err := ProviderVersion(nil, "custom")
+		Expect(err).To(MatchError(ContainSubstring(`neither "custom" nor KUBEVIRT_PROVIDER contain a Kubernetes version`)))
+	})
+
+	It("should drop pre-releases so constraints match release candidates", func() {
+		version, err := ParseVersion("v1.36.0-rc.1+abcdef")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(version.String()).To(Equal("1.36.0"))
+	})
+})
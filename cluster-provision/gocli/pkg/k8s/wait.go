@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// pollInterval is the time between two readiness checks of the same object
+var pollInterval = 5 * time.Second
+
+var (
+	deploymentGVK  = appsv1.SchemeGroupVersion.WithKind("Deployment")
+	daemonSetGVK   = appsv1.SchemeGroupVersion.WithKind("DaemonSet")
+	statefulSetGVK = appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+	pvcGVK         = corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim")
+	crdGVK         = apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition")
+)
+
+// ReadyFunc reports whether an object is ready, and if it is not, a short description of what is still missing
+type ReadyFunc func(obj *unstructured.Unstructured) (bool, string, error)
+
+// WaitFor polls the object until ready reports it as ready or the context is done.
+// Objects which do not exist yet are waited for as well, as they are often created by operators.
+// Progress is logged every time the reason the object is not ready changes
+func WaitFor(ctx context.Context, client K8sDynamicClient, gvk schema.GroupVersionKind, name, ns string, ready ReadyFunc) error {
+	return poll(ctx, gvk.Kind+" "+objectKeyOf(name, ns), "ready", func() (bool, string, error) {
+		obj, err := client.Get(gvk, name, ns)
+		if err != nil {
+			return false, err.Error(), nil
+		}
+		return ready(obj)
+	})
+}
+
+// WaitForDeletion waits until the object is gone, e.g. because an operator had to run its finalizers first
+func WaitForDeletion(ctx context.Context, client K8sDynamicClient, gvk schema.GroupVersionKind, name, ns string) error {
+	return poll(ctx, gvk.Kind+" "+objectKeyOf(name, ns), "deleted", func() (bool, string, error) {
+		_, err := client.Get(gvk, name, ns)
+		if errors.IsNotFound(err) {
+			return true, "", nil
+		}
+		if err != nil {
+			return false, err.Error(), nil
+		}
+		return false, "still exists", nil
+	})
+}
+
+func poll(ctx context.Context, what, state string, check func() (bool, string, error)) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lastReason := ""
+	for {
+		ok, reason, err := check()
+		if err != nil {
+			return fmt.Errorf("error checking whether %s is %s: %w", what, state, err)
+		}
+		if ok {
+			logrus.Infof("%s is %s", what, state)
+			return nil
+		}
+
+		if reason != lastReason {
+			logrus.Infof("Waiting for %s: %s", what, reason)
+			lastReason = reason
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s: %s", what, lastReason)
+		case <-ticker.C:
+		}
+	}
+}
+
+func objectKeyOf(name, ns string) string {
+	if ns == "" {
+		return name
+	}
+	return ns + "/" + name
+}
+
+// WaitForDeploymentRollout waits until all replicas of the deployment run the latest template and are available
+func WaitForDeploymentRollout(ctx context.Context, client K8sDynamicClient, name, ns string) error {
+	return WaitFor(ctx, client, deploymentGVK, name, ns, DeploymentRolledOut)
+}
+
+// DeploymentRolledOut reports a deployment as ready once all replicas run the latest template and are available
+func DeploymentRolledOut(obj *unstructured.Unstructured) (bool, string, error) {
+	deployment := &appsv1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, deployment); err != nil {
+		return false, "", err
+	}
+
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false, "new spec not observed yet", nil
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas updated", deployment.Status.UpdatedReplicas, replicas), nil
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return false, fmt.Sprintf("%d old replicas pending termination", deployment.Status.Replicas-deployment.Status.UpdatedReplicas), nil
+	}
+	if deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas {
+		return false, fmt.Sprintf("%d of %d updated replicas available", deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas), nil
+	}
+	return true, "", nil
+}
+
+// WaitForDaemonSetRollout waits until the daemonset pods on all scheduled nodes run the latest template and are available
+func WaitForDaemonSetRollout(ctx context.Context, client K8sDynamicClient, name, ns string) error {
+	return WaitFor(ctx, client, daemonSetGVK, name, ns, DaemonSetRolledOut)
+}
+
+// DaemonSetRolledOut reports a daemonset as ready once the pods on all scheduled nodes run the latest template and are available
+func DaemonSetRolledOut(obj *unstructured.Unstructured) (bool, string, error) {
+	daemonSet := &appsv1.DaemonSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, daemonSet); err != nil {
+		return false, "", err
+	}
+
+	if daemonSet.Status.ObservedGeneration < daemonSet.Generation {
+		return false, "new spec not observed yet", nil
+	}
+	desired := daemonSet.Status.DesiredNumberScheduled
+	if daemonSet.Status.UpdatedNumberScheduled < desired {
+		return false, fmt.Sprintf("%d of %d pods updated", daemonSet.Status.UpdatedNumberScheduled, desired), nil
+	}
+	if daemonSet.Status.NumberAvailable < desired {
+		return false, fmt.Sprintf("%d of %d updated pods available", daemonSet.Status.NumberAvailable, desired), nil
+	}
+	return true, "", nil
+}
+
+// WaitForStatefulSetRollout waits until all replicas of the statefulset run the latest revision and are ready
+func WaitForStatefulSetRollout(ctx context.Context, client K8sDynamicClient, name, ns string) error {
+	return WaitFor(ctx, client, statefulSetGVK, name, ns, StatefulSetRolledOut)
+}
+
+// StatefulSetRolledOut reports a statefulset as ready once all replicas run the latest revision and are ready
+func StatefulSetRolledOut(obj *unstructured.Unstructured) (bool, string, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, statefulSet); err != nil {
+		return false, "", err
+	}
+
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation {
+		return false, "new spec not observed yet", nil
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return false, fmt.Sprintf("%d of %d replicas ready", statefulSet.Status.ReadyReplicas, replicas), nil
+	}
+	if statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision {
+		return false, fmt.Sprintf("%d of %d replicas updated to revision %s", statefulSet.Status.UpdatedReplicas, replicas, statefulSet.Status.UpdateRevision), nil
+	}
+	return true, "", nil
+}
+
+// WaitForCRDEstablished waits until the API server serves the resources of the CRD
+func WaitForCRDEstablished(ctx context.Context, client K8sDynamicClient, name string) error {
+	return WaitForCondition(ctx, client, crdGVK, name, "", string(apiextensionsv1.Established))
+}
+
+// WaitForPVCBound waits until the claim is bound to a volume
+func WaitForPVCBound(ctx context.Context, client K8sDynamicClient, name, ns string) error {
+	return WaitForPhase(ctx, client, pvcGVK, name, ns, string(corev1.ClaimBound))
+}
+
+// WaitForCondition waits until the condition of the given type in status.conditions is True
+func WaitForCondition(ctx context.Context, client K8sDynamicClient, gvk schema.GroupVersionKind, name, ns, conditionType string) error {
+	return WaitFor(ctx, client, gvk, name, ns, ConditionTrue(conditionType))
+}
+
+// ConditionTrue reports an object as ready once the condition of the given type in status.conditions is True
+func ConditionTrue(conditionType string) ReadyFunc {
+	return func(obj *unstructured.Unstructured) (bool, string, error) {
+		conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		if err != nil {
+			return false, "", err
+		}
+
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok || condition["type"] != conditionType {
+				continue
+			}
+			if condition["status"] == "True" {
+				return true, "", nil
+			}
+			return false, fmt.Sprintf("condition %s is %v: %v", conditionType, condition["status"], condition["message"]), nil
+		}
+		return false, fmt.Sprintf("condition %s not reported yet", conditionType), nil
+	}
+}
+
+// WaitForPhase waits until status.phase of the object matches phase
+func WaitForPhase(ctx context.Context, client K8sDynamicClient, gvk schema.GroupVersionKind, name, ns, phase string) error {
+	return WaitFor(ctx, client, gvk, name, ns, PhaseIs(phase))
+}
+
+// PhaseIs reports an object as ready once status.phase matches phase
+func PhaseIs(phase string) ReadyFunc {
+	return func(obj *unstructured.Unstructured) (bool, string, error) {
+		current, _, err := unstructured.NestedString(obj.Object, "status", "phase")
+		if err != nil {
+			return false, "", err
+		}
+		if current != phase {
+			return false, fmt.Sprintf("phase is %q, expected %q", current, phase), nil
+		}
+		return true, "", nil
+	}
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Wait", func() {
+	var (
+		client *k8sDynamicClientImpl
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		originalInterval := pollInterval
+		pollInterval = 10 * time.Millisecond
+		DeferCleanup(func() {
+			pollInterval = originalInterval
+		})
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		DeferCleanup(cancel)
+	})
+
+	apply := func(manifest string) {
+		obj, err := SerializeIntoObject([]byte(manifest))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.Apply(obj)).To(Succeed())
+	}
+
+	const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+  namespace: test
+spec:
+  replicas: 2
+`
+
+	It("should return once the deployment rolled out", func() {
+		client = NewTestClient()
+		apply(deployment)
+
+		Expect(WaitForDeploymentRollout(ctx, client, "operator", "test")).To(Succeed())
+	})
+
+	It("should report why the deployment did not roll out in time", func() {
+		client = NewTestClient()
+		apply(deployment)
+		obj, err := client.Get(deploymentGVK, "operator", "test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unstructured.SetNestedField(obj.Object, int64(1), "status", "availableReplicas")).To(Succeed())
+		Expect(client.Update(obj)).To(Succeed())
+
+		err = WaitForDeploymentRollout(ctx, client, "operator", "test")
+		Expect(err).To(MatchError("timed out waiting for Deployment test/operator: 1 of 2 updated replicas available"))
+	})
+
+	It("should wait for objects which are created later", func() {
+		client = NewTestClient()
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+			apply(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`)
+		}()
+
+		Expect(WaitForCRDEstablished(ctx, client, "widgets.example.com")).To(Succeed())
+	})
+
+	It("should wait for the phase of the object", func() {
+		client = NewTestClient()
+		apply(`apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: claim
+  namespace: test
+status:
+  phase: Pending
+`)
+
+		err := WaitForPVCBound(ctx, client, "claim", "test")
+		Expect(err).To(MatchError(`timed out waiting for PersistentVolumeClaim test/claim: phase is "Pending", expected "Bound"`))
+	})
+})
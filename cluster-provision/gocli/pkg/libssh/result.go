@@ -0,0 +1,114 @@
+package libssh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// exitCodeUnknown is reported when the remote side never sent an exit status,
+	// e.g. because the connection dropped or the session could not be started
+	exitCodeUnknown = -1
+
+	maxErrorCmdLen    = 120
+	maxErrorStderrLen = 2048
+)
+
+// Result holds everything that is known about a command after it ran on a node
+type Result struct {
+	Cmd      string
+	NodeIdx  int
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+// CommandError is returned when a command could not be run on a node or exited with a non zero status.
+// It carries the full Result so callers can inspect the remote output
+type CommandError struct {
+	Result *Result
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "command %q on node%02d ", truncate(e.Result.Cmd, maxErrorCmdLen), e.Result.NodeIdx)
+	if e.Result.ExitCode == exitCodeUnknown {
+		fmt.Fprintf(&b, "failed after %s: %v", e.Result.Duration.Round(time.Millisecond), e.Err)
+	} else {
+		fmt.Fprintf(&b, "exited with code %d after %s", e.Result.ExitCode, e.Result.Duration.Round(time.Millisecond))
+	}
+
+	if stderr := strings.TrimSpace(e.Result.Stderr); stderr != "" {
+		if len(stderr) > maxErrorStderrLen {
+			stderr = "..." + stderr[len(stderr)-maxErrorStderrLen:]
+		}
+		fmt.Fprintf(&b, ", stderr: %s", stderr)
+	}
+	return b.String()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 && i < max {
+		return s[:i] + "..."
+	}
+	if len(s) > max {
+		return s[:max] + "..."
+	}
+	return s
+}
+
+var transcript = struct {
+	sync.Mutex
+	dir string
+}{}
+
+// EnableTranscript makes all clients append every command they run, together with its output,
+// exit code and duration, to a per node log file (dir/nodeXX.log)
+func EnableTranscript(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create transcript directory %s: %w", dir, err)
+	}
+
+	transcript.Lock()
+	defer transcript.Unlock()
+	transcript.dir = dir
+	return nil
+}
+
+func writeTranscript(r *Result, started time.Time) error {
+	transcript.Lock()
+	defer transcript.Unlock()
+	if transcript.dir == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(transcript.dir, fmt.Sprintf("node%02d.log", r.NodeIdx)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "=== %s exit=%d duration=%s\n", started.UTC().Format(time.RFC3339), r.ExitCode, r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "$ %s\n", r.Cmd)
+	if r.Stdout != "" {
+		fmt.Fprintf(&b, "--- stdout\n%s\n", strings.TrimSuffix(r.Stdout, "\n"))
+	}
+	if r.Stderr != "" {
+		fmt.Fprintf(&b, "--- stderr\n%s\n", strings.TrimSuffix(r.Stderr, "\n"))
+	}
+
+	_, err = f.WriteString(b.String())
+	return err
+}
@@ -0,0 +1,88 @@
+package libssh
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLibssh(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Libssh Suite")
+}
+
+var _ = Describe("Result", func() {
+	Describe("CommandError", func() {
+		It("should report the exit code and stderr of the remote command", func() {
+			err := &CommandError{
+				Result: &Result{
+					Cmd:      "lspci -D -d 8086:2668",
+					NodeIdx:  2,
+					Stderr:   "lspci: command not found\n",
+					ExitCode: 127,
+					Duration: 1500 * time.Millisecond,
+				},
+				Err: errors.New("Process exited with status 127"),
+			}
+
+			Expect(err.Error()).To(Equal(`command "lspci -D -d 8086:2668" on node02 exited with code 127 after 1.5s, stderr: lspci: command not found`))
+		})
+
+		It("should report the underlying error when no exit code was received", func() {
+			connErr := errors.New("connection reset by peer")
+			err := &CommandError{
+				Result: &Result{
+					Cmd:      "echo hello",
+					NodeIdx:  1,
+					ExitCode: exitCodeUnknown,
+				},
+				Err: connErr,
+			}
+
+			Expect(err.Error()).To(Equal(`command "echo hello" on node01 failed after 0s: connection reset by peer`))
+			Expect(errors.Is(err, connErr)).To(BeTrue())
+		})
+
+		It("should only keep the first line of multi line commands", func() {
+			err := &CommandError{
+				Result: &Result{
+					Cmd:      "set -e\nexit 1",
+					NodeIdx:  1,
+					ExitCode: 1,
+				},
+			}
+
+			Expect(err.Error()).To(HavePrefix(`command "set -e..." on node01 exited with code 1`))
+		})
+	})
+
+	Describe("Transcript", func() {
+		AfterEach(func() {
+			transcript.dir = ""
+		})
+
+		It("should not write anything when the transcript is disabled", func() {
+			Expect(writeTranscript(&Result{Cmd: "true", NodeIdx: 1}, time.Now())).To(Succeed())
+		})
+
+		It("should append every command to the per node log", func() {
+			dir := GinkgoT().TempDir()
+			Expect(EnableTranscript(dir)).To(Succeed())
+
+			Expect(writeTranscript(&Result{Cmd: "hostname", NodeIdx: 1, Stdout: "node01\n"}, time.Now())).To(Succeed())
+			Expect(writeTranscript(&Result{Cmd: "false", NodeIdx: 1, ExitCode: 1, Stderr: "boom"}, time.Now())).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(dir, "node01.log"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Count(string(content), "=== ")).To(Equal(2))
+			Expect(string(content)).To(ContainSubstring("exit=0 duration=0s\n$ hostname\n--- stdout\nnode01\n"))
+			Expect(string(content)).To(ContainSubstring("exit=1 duration=0s\n$ false\n--- stderr\nboom\n"))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/bramvdbogaerde/go-scp"
 	"github.com/sirupsen/logrus"
@@ -25,6 +27,7 @@ var sshKey []byte
 type Client interface {
 	Command(cmd string) error
 	CommandWithNoStdOut(cmd string) (string, error)
+	CommandWithResult(cmd string) (*Result, error)
 	CopyRemoteFile(remotePathToCopy string, out io.Writer) error
 	SCP(destPath string, contents io.Reader) error
 }
@@ -69,29 +72,32 @@ func GetSSHUser() string {
 }
 
 func (s *SSHClientImpl) Command(cmd string) error {
-	return s.executeCommand(cmd, os.Stdout, os.Stderr)
+	_, err := s.executeCommand(cmd, os.Stdout, os.Stderr)
+	return err
 }
 
 func (s *SSHClientImpl) CommandWithNoStdOut(cmd string) (string, error) {
-	var stdout, stderr bytes.Buffer
-
-	err := s.executeCommand(cmd, &stdout, &stderr)
+	result, err := s.executeCommand(cmd, io.Discard, io.Discard)
 	if err != nil {
-		return "", fmt.Errorf("%w, %s", err, stderr.String())
+		return "", err
 	}
-	return stdout.String(), nil
+	return result.Stdout, nil
+}
+
+// CommandWithResult runs the command without streaming its output and returns everything that is known about the run.
+// The result is returned on failure as well, the error is a *CommandError in that case
+func (s *SSHClientImpl) CommandWithResult(cmd string) (*Result, error) {
+	return s.executeCommand(cmd, io.Discard, io.Discard)
 }
 
 // Copies a file from a jump host after first establishing a connection with the forwarded port by dnsmasq
 func (s *SSHClientImpl) SCP(fileName string, contents io.Reader) error {
-	if s.client == nil {
-		err := s.initClient()
-		if err != nil {
-			return err
-		}
+	client, err := s.getClient()
+	if err != nil {
+		return err
 	}
 
-	scpClient, err := scp.NewClientBySSH(s.client)
+	scpClient, err := scp.NewClientBySSH(client)
 	if err != nil {
 		return err
 	}
@@ -111,14 +117,12 @@ func (s *SSHClientImpl) SCP(fileName string, contents io.Reader) error {
 
 // Copies a file on a jump host after first establishing a connection with the forwarded port by dnsmasq
 func (s *SSHClientImpl) CopyRemoteFile(remotePathToCopy string, target io.Writer) error {
-	if s.client == nil {
-		err := s.initClient()
-		if err != nil {
-			return err
-		}
+	client, err := s.getClient()
+	if err != nil {
+		return err
 	}
 
-	session, err := s.client.NewSession()
+	session, err := client.NewSession()
 	if err != nil {
 		return err
 	}
@@ -193,14 +197,52 @@ func (s *SSHClientImpl) CopyRemoteFile(remotePathToCopy string, target io.Writer
 	return err
 }
 
-func (s *SSHClientImpl) executeCommand(cmd string, outWriter, errWriter io.Writer) error {
-	if s.client == nil {
-		err := s.initClient()
-		if err != nil {
-			return err
+func (s *SSHClientImpl) executeCommand(cmd string, outWriter, errWriter io.Writer) (*Result, error) {
+	if len(cmd) > 0 {
+		firstCmdChar := cmd[0]
+		// indicates the command is a script or a script with params
+		if string(firstCmdChar) == "/" || string(firstCmdChar) == "-" {
+			cmd = "sudo /bin/bash " + cmd
 		}
 	}
-	session, err := s.client.NewSession()
+
+	var stdout, stderr bytes.Buffer
+	result := &Result{
+		Cmd:      cmd,
+		NodeIdx:  s.nodeIdx,
+		ExitCode: exitCodeUnknown,
+	}
+
+	started := time.Now()
+	err := s.runSession(cmd, io.MultiWriter(outWriter, &stdout), io.MultiWriter(errWriter, &stderr))
+	result.Duration = time.Since(started)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+	}
+
+	if terr := writeTranscript(result, started); terr != nil {
+		logrus.Warnf("[node %d]: failed to write ssh transcript: %v", s.nodeIdx, terr)
+	}
+
+	if err != nil {
+		return result, &CommandError{Result: result, Err: err}
+	}
+	return result, nil
+}
+
+func (s *SSHClientImpl) runSession(cmd string, outWriter, errWriter io.Writer) error {
+	client, err := s.getClient()
+	if err != nil {
+		return err
+	}
+	session, err := client.NewSession()
 	if err != nil {
 		return err
 	}
@@ -209,25 +251,25 @@ func (s *SSHClientImpl) executeCommand(cmd string, outWriter, errWriter io.Write
 	session.Stdout = outWriter
 	session.Stderr = errWriter
 
-	if len(cmd) > 0 {
-		firstCmdChar := cmd[0]
-		// indicates the command is a script or a script with params
-		if string(firstCmdChar) == "/" || string(firstCmdChar) == "-" {
-			cmd = "sudo /bin/bash " + cmd
-		}
-	}
 	logrus.Infof("[node %d]: %s", s.nodeIdx, cmd)
+	return session.Run(cmd)
+}
 
-	err = session.Run(cmd)
-	if err != nil {
-		return fmt.Errorf("failed to execute command: %s", cmd)
+// getClient returns the connection to the node, it is established on first use.
+// The client is shared by opts running concurrently, so only the first caller connects
+func (s *SSHClientImpl) getClient() (*ssh.Client, error) {
+	s.initMutex.Lock()
+	defer s.initMutex.Unlock()
+	if s.client == nil {
+		if err := s.initClient(); err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	return s.client, nil
 }
 
+// initClient has to be called with initMutex held
 func (s *SSHClientImpl) initClient() error {
-	s.initMutex.Lock()
-	defer s.initMutex.Unlock()
 	client, err := ssh.Dial("tcp", net.JoinHostPort("127.0.0.1", fmt.Sprint(s.sshPort)), s.config)
 	if err != nil {
 		return fmt.Errorf("failed to connect to SSH server: %v", err)
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	gomock "go.uber.org/mock/gomock"
+	libssh "kubevirt.io/kubevirtci/cluster-provision/gocli/pkg/libssh"
 )
 
 // MockSSHClient is a mock of SSHClient interface.
@@ -68,6 +69,21 @@ func (mr *MockSSHClientMockRecorder) CommandWithNoStdOut(cmd any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommandWithNoStdOut", reflect.TypeOf((*MockSSHClient)(nil).CommandWithNoStdOut), cmd)
 }
 
+// CommandWithResult mocks base method.
+func (m *MockSSHClient) CommandWithResult(cmd string) (*libssh.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommandWithResult", cmd)
+	ret0, _ := ret[0].(*libssh.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommandWithResult indicates an expected call of CommandWithResult.
+func (mr *MockSSHClientMockRecorder) CommandWithResult(cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommandWithResult", reflect.TypeOf((*MockSSHClient)(nil).CommandWithResult), cmd)
+}
+
 // CopyRemoteFile mocks base method.
 func (m *MockSSHClient) CopyRemoteFile(remotePathToCopy string, out io.Writer) error {
 	m.ctrl.T.Helper()
@@ -49,10 +49,56 @@ else
   exit 1
 fi
 
+# gocli attaches up to 8 igb NICs with the MAC prefixes 52:55:00:d1:57 to 52:55:00:d1:5e, they are named sriov0 to sriov7
 function create_sriov_udev_rule() {
-    cat > /etc/udev/rules.d/99-kubevirtci-sriov-net.rules <<'EOF'
-ACTION=="add", SUBSYSTEM=="net", DRIVERS=="igb", NAME="sriov0"
-EOF
+    rm -f /etc/udev/rules.d/99-kubevirtci-sriov-net.rules
+    for i in $(seq 0 7); do
+        echo "ACTION==\"add\", SUBSYSTEM==\"net\", DRIVERS==\"igb\", ATTR{address}==\"52:55:00:d1:$(printf "%02x" $((0x57 + i))):*\", NAME=\"sriov${i}\"" >> /etc/udev/rules.d/99-kubevirtci-sriov-net.rules
+    done
+}
+
+# Cilium and OVN-Kubernetes only ship helm charts, their manifests are rendered for the cluster network of gocli
+function render_cni_manifests() {
+    local -r helm_version=v3.16.3
+    local -r cilium_version=1.16.5
+    local -r ovn_kubernetes_version=v1.0.0
+    local -r work_dir=$(mktemp -d)
+
+    local helm_arch=amd64
+    if [ "$arch" == "aarch64" ]; then
+        helm_arch=arm64
+    elif [ "$arch" == "s390x" ]; then
+        helm_arch=s390x
+    fi
+    curl -L https://get.helm.sh/helm-${helm_version}-linux-${helm_arch}.tar.gz | tar -xz -C $work_dir
+    local -r helm=$work_dir/linux-${helm_arch}/helm
+
+    # kube-proxy keeps implementing services, the pod IPs are allocated from the pod CIDRs of the nodes, other CNI
+    # configs, e.g. the one of multus, are kept
+    local -r cilium_args="--repo https://helm.cilium.io --version ${cilium_version} --namespace kube-system \
+        --set ipam.mode=kubernetes --set kubeProxyReplacement=false --set cni.exclusive=false \
+        --set routingMode=tunnel --set tunnelProtocol=vxlan --set operator.replicas=1"
+    $helm template cilium cilium $cilium_args --set ipv6.enabled=true > /etc/kubernetes/cilium.yaml
+    $helm template cilium cilium $cilium_args --set ipv4.enabled=false --set ipv6.enabled=true > /etc/kubernetes/cilium_ipv6.yaml
+
+    mkdir $work_dir/ovn-kubernetes
+    curl -L https://github.com/ovn-kubernetes/ovn-kubernetes/archive/refs/tags/${ovn_kubernetes_version}.tar.gz | tar -xz --strip-components=1 -C $work_dir/ovn-kubernetes
+    local -r ovn_kubernetes_chart=$work_dir/ovn-kubernetes/helm/ovn-kubernetes
+    # the pod and service networks have to match the subnets of kubeadm_ovn.conf and kubeadm_ovn_ipv6.conf, IPv6 host
+    # subnets of OVN-Kubernetes are /64
+    local -r ovn_kubernetes_args="-f $ovn_kubernetes_chart/values-single-node-zone.yaml \
+        --set global.image.repository=ghcr.io/ovn-kubernetes/ovn-kubernetes/ovn-kube-ubuntu \
+        --set global.image.tag=${ovn_kubernetes_version}"
+    $helm template ovn-kubernetes $ovn_kubernetes_chart $ovn_kubernetes_args \
+        --set k8sAPIServer=https://192.168.66.101:6443 \
+        --set podNetwork="10.244.0.0/16/24\,fd10:244::/48/64" \
+        --set serviceNetwork="10.96.0.0/12\,fd10:96::/108" > /etc/kubernetes/ovn-kubernetes.yaml
+    $helm template ovn-kubernetes $ovn_kubernetes_chart $ovn_kubernetes_args \
+        --set k8sAPIServer="https://[fd00::101]:6443" \
+        --set podNetwork=fd00:10:244::/48/64 \
+        --set serviceNetwork=fd00:10:96::/112 > /etc/kubernetes/ovn-kubernetes_ipv6.yaml
+
+    rm -rf $work_dir
 }
 
 function pull_container_retry() {
@@ -165,6 +211,8 @@ patch $flannel_manifest_ipv6 $flannel_ipv6_diff
 cp /tmp/knp.do-not-change.yaml $knp_manifest
 patch $knp_manifest $knp_diff
 
+render_cni_manifests
+
 cp /tmp/local-volume.yaml /provision/local-volume.yaml
 
 # Create drop-in config files for kubelet
@@ -221,7 +269,7 @@ systemctl enable crio kubelet --now
 # configure additional settings for cni plugin
 cat <<EOF >/etc/NetworkManager/conf.d/001-calico.conf
 [keyfile]
-unmanaged-devices=interface-name:cali*;interface-name:tunl*
+unmanaged-devices=interface-name:cali*;interface-name:tunl*;interface-name:cilium_*;interface-name:lxc*;interface-name:ovn-k8s-*;interface-name:genev_sys_*;interface-name:br-int;interface-name:breth0
 EOF
 
 # Use dhclient to have expected hostname behaviour
@@ -330,12 +378,20 @@ kubeadm_flannel_ipv6_manifest="/etc/kubernetes/kubeadm_flannel_ipv6.conf"
 kubeadm_flannel_raw="/tmp/kubeadm_flannel.conf"
 kubeadm_flannel="/etc/kubernetes/kubeadm_flannel.conf"
 
+kubeadm_ovn_raw="/tmp/kubeadm_ovn.conf"
+kubeadm_ovn="/etc/kubernetes/kubeadm_ovn.conf"
+kubeadm_ovn_ipv6_raw="/tmp/kubeadm_ovn_ipv6.conf"
+kubeadm_ovn_ipv6="/etc/kubernetes/kubeadm_ovn_ipv6.conf"
+
 envsubst < $kubeadm_raw > $kubeadm_manifest
 envsubst < $kubeadm_raw_ipv6 > $kubeadm_manifest_ipv6
 
 envsubst < $kubeadm_flannel_raw > $kubeadm_flannel
 envsubst < $kubeadm_flannel_ipv6_raw > $kubeadm_flannel_ipv6_manifest
 
+envsubst < $kubeadm_ovn_raw > $kubeadm_ovn
+envsubst < $kubeadm_ovn_ipv6_raw > $kubeadm_ovn_ipv6
+
 until ip address show dev eth0 | grep global | grep inet6; do sleep 1; done
 
 if ! kubeadm init --config $kubeadm_flannel -v5; then
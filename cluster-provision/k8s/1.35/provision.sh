@@ -7,12 +7,15 @@ ARCH=$(uname -m)
 KUBEVIRTCI_SHARED_DIR=/var/lib/kubevirtci
 mkdir -p $KUBEVIRTCI_SHARED_DIR
 export ISTIO_VERSION=1.30.2
+export VELERO_VERSION=v1.14.1
 cat << EOF > $KUBEVIRTCI_SHARED_DIR/shared_vars.sh
 #!/bin/bash
 set -ex
 export KUBELET_CGROUP_ARGS="--cgroup-driver=systemd --runtime-cgroups=/systemd/system.slice --kubelet-cgroups=/systemd/system.slice"
 export ISTIO_VERSION=${ISTIO_VERSION}
 export ISTIO_BIN_DIR="/opt/istio-${ISTIO_VERSION}/bin"
+export VELERO_VERSION=${VELERO_VERSION}
+export VELERO_BIN_DIR="/opt/velero-${VELERO_VERSION}/bin"
 EOF
 source $KUBEVIRTCI_SHARED_DIR/shared_vars.sh
 
@@ -82,6 +85,14 @@ export PATH="$ISTIO_BIN_DIR:$PATH"
   tar -xvf ./istio-${ISTIO_VERSION}-linux-amd64.tar.gz --strip-components=2 -C ${ISTIO_BIN_DIR} istio-${ISTIO_VERSION}/bin/istioctl
   chmod +x "$ISTIO_BIN_DIR/istioctl"
 )
+# Install the velero CLI
+(
+  set -E
+  mkdir -p "$VELERO_BIN_DIR"
+  curl -L https://github.com/vmware-tanzu/velero/releases/download/${VELERO_VERSION}/velero-${VELERO_VERSION}-linux-amd64.tar.gz -O
+  tar -xvf ./velero-${VELERO_VERSION}-linux-amd64.tar.gz --strip-components=1 -C ${VELERO_BIN_DIR} velero-${VELERO_VERSION}-linux-amd64/velero
+  chmod +x "$VELERO_BIN_DIR/velero"
+)
 
 dnf install -y container-selinux
 
@@ -57,6 +57,42 @@ if [[ ${_cri_bin} = podman* ]]; then
     _cli="${_cli} -v ${KUBEVIRTCI_CONFIG_PATH}/$KUBEVIRT_PROVIDER:/kubevirtci_config"
 fi
 
+# add-on directories are read by gocli itself, so they have to be mounted at the same path into its container
+for addon_dir in ${KUBEVIRT_ADDONS}; do
+    addon_dir=$(realpath "${addon_dir}")
+    _cli="${_cli} -v ${addon_dir}:${addon_dir}:ro,Z"
+done
+
+# the kubevirt manifests are read by gocli itself as well
+if [ -n "${KUBEVIRT_KUBEVIRT_MANIFESTS}" ]; then
+    kubevirt_manifests=$(realpath "${KUBEVIRT_KUBEVIRT_MANIFESTS}")
+    _cli="${_cli} -v ${kubevirt_manifests}:${kubevirt_manifests}:ro,Z"
+fi
+
+# and so is the file with the kubevirt configuration
+if [ -n "${KUBEVIRT_KUBEVIRT_CONFIG}" ]; then
+    kubevirt_config=$(realpath "${KUBEVIRT_KUBEVIRT_CONFIG}")
+    _cli="${_cli} -v ${kubevirt_config}:${kubevirt_config}:ro,Z"
+fi
+
+# and so is the kwok node template
+if [ -n "${KUBEVIRT_KWOK_NODE_TEMPLATE}" ]; then
+    kwok_node_template=$(realpath "${KUBEVIRT_KWOK_NODE_TEMPLATE}")
+    _cli="${_cli} -v ${kwok_node_template}:${kwok_node_template}:ro,Z"
+fi
+
+# the extra prometheus directory is read on every gocli addon sync prometheus too
+if [ -n "${KUBEVIRT_PROMETHEUS_EXTRA}" ]; then
+    prometheus_extra=$(realpath "${KUBEVIRT_PROMETHEUS_EXTRA}")
+    _cli="${_cli} -v ${prometheus_extra}:${prometheus_extra}:ro,Z"
+fi
+
+# and so is the admission configuration of Pod Security Admission
+if [ -n "${KUBEVIRT_PSA_CONFIG}" ]; then
+    psa_config=$(realpath "${KUBEVIRT_PSA_CONFIG}")
+    _cli="${_cli} -v ${psa_config}:${psa_config}:ro,Z"
+fi
+
 _cli="${_cli} ${_cli_container}"
 
 function _main_ip() {
@@ -137,14 +173,31 @@ function _add_common_params() {
 
     if [ $KUBEVIRT_PSA == "true" ]; then
         params=" --enable-psa $params"
+        if [ -n "$KUBEVIRT_PSA_CONFIG" ]; then
+            params=" --psa-config=$(realpath $KUBEVIRT_PSA_CONFIG) $params"
+        fi
+        if [ -n "$KUBEVIRT_PSA_ENFORCE" ]; then
+            params=" --psa-enforce=$KUBEVIRT_PSA_ENFORCE $params"
+        fi
+        if [ -n "$KUBEVIRT_PSA_AUDIT" ]; then
+            params=" --psa-audit=$KUBEVIRT_PSA_AUDIT $params"
+        fi
+        if [ -n "$KUBEVIRT_PSA_WARN" ]; then
+            params=" --psa-warn=$KUBEVIRT_PSA_WARN $params"
+        fi
+        if [ -n "$KUBEVIRT_PSA_EXEMPT_NAMESPACES" ]; then
+            params=" --psa-exempt-namespaces=$KUBEVIRT_PSA_EXEMPT_NAMESPACES $params"
+        fi
     fi
 
     if [ $KUBEVIRT_SINGLE_STACK == "true" ]; then
         params=" --single-stack $params"
     fi
 
-    if [ $KUBEVIRT_FLANNEL == "true" ]; then
-        params=" --flannel $params"
+    if [ -n "$KUBEVIRT_CNI" ]; then
+        params=" --cni=$KUBEVIRT_CNI $params"
+    elif [ $KUBEVIRT_FLANNEL == "true" ]; then
+        params=" --cni=flannel $params"
     fi
 
     if [ $KUBEVIRT_NO_ETCD_FSYNC == "true" ]; then
@@ -160,11 +213,20 @@ function _add_common_params() {
     fi
 
     if [ $KUBEVIRT_DEPLOY_NFS_CSI == "true" ]; then
-        if [ -z $KUBEVIRT_NFS_DIR ]; then
-            >&2 echo "NFS requested but no NFS directory specified (KUBEVIRT_NFS_DIR)"
-            exit 1
+        # without KUBEVIRT_NFS_DIR the nfs server exports a volume of the cluster
+        if [ -n "$KUBEVIRT_NFS_DIR" ]; then
+            params=" --nfs-data $KUBEVIRT_NFS_DIR $params"
+        fi
+        if [ -n "$KUBEVIRT_NFS_TMPFS_SIZE" ]; then
+            params=" --nfs-tmpfs-size=$KUBEVIRT_NFS_TMPFS_SIZE $params"
+        fi
+        if [ -n "$KUBEVIRT_NFS_EXPORT_OPTIONS" ]; then
+            params=" --nfs-export-options=$KUBEVIRT_NFS_EXPORT_OPTIONS $params"
+        fi
+        if [ "$KUBEVIRT_NFS_SECOND_EXPORT" == "true" ]; then
+            params=" --nfs-second-export $params"
         fi
-        params=" --enable-nfs-csi --nfs-data $KUBEVIRT_NFS_DIR $params"
+        params=" --enable-nfs-csi $params"
     fi
 
     # alternate (new) way to specify storage providers
@@ -172,6 +234,38 @@ function _add_common_params() {
         params=" --enable-ceph $params"
     fi
 
+    if [[ $KUBEVIRT_STORAGE == "rook-ceph" ]] && [[ $KUBEVIRT_PROVIDER_EXTRA_ARGS != *"--enable-ceph"* ]]; then
+        params=" --enable-ceph --ceph-default-storage-class=false $params"
+    fi
+
+    if [ -n "$KUBEVIRT_CEPH_OSDS" ]; then
+        params=" --ceph-osds=$KUBEVIRT_CEPH_OSDS $params"
+    fi
+
+    if [ -n "$KUBEVIRT_CEPH_OSD_SIZE" ]; then
+        params=" --ceph-osd-size=$KUBEVIRT_CEPH_OSD_SIZE $params"
+    fi
+
+    if [ -n "$KUBEVIRT_CEPH_REPLICAS" ]; then
+        params=" --ceph-replicas=$KUBEVIRT_CEPH_REPLICAS $params"
+    fi
+
+    if [ "$KUBEVIRT_CEPH_FILESYSTEM" == "true" ]; then
+        params=" --ceph-filesystem $params"
+    fi
+
+    if [ "$KUBEVIRT_CEPH_OBJECT_STORE" == "true" ]; then
+        params=" --ceph-object-store $params"
+    fi
+
+    if [[ $KUBEVIRT_STORAGE == "csi-hostpath" ]] && [[ $KUBEVIRT_PROVIDER_EXTRA_ARGS != *"--enable-csi-hostpath"* ]]; then
+        params=" --enable-csi-hostpath $params"
+    fi
+
+    if [[ $KUBEVIRT_STORAGE == "csi-hostpath-default" ]] && [[ $KUBEVIRT_PROVIDER_EXTRA_ARGS != *"--enable-csi-hostpath"* ]]; then
+        params=" --enable-csi-hostpath --csi-hostpath-default $params"
+    fi
+
     if [[ $KUBEVIRT_DEPLOY_PROMETHEUS == "true" ]] &&
         [[ $KUBEVIRT_PROVIDER_EXTRA_ARGS != *"--enable-prometheus"* ]]; then
         params=" --enable-prometheus $params"
@@ -185,6 +279,15 @@ function _add_common_params() {
             [[ $KUBEVIRT_PROVIDER_EXTRA_ARGS != *"--enable-grafana"* ]]; then
             params=" --enable-grafana $params"
         fi
+
+        if [ -n "$KUBEVIRT_PROMETHEUS_EXTRA" ]; then
+            params=" --prometheus-extra=$(realpath $KUBEVIRT_PROMETHEUS_EXTRA) $params"
+        fi
+    fi
+
+    if [[ $KUBEVIRT_DEPLOY_LOGGING == "true" ]] &&
+        [[ $KUBEVIRT_PROVIDER_EXTRA_ARGS != *"--enable-logging"* ]]; then
+        params=" --enable-logging $params"
     fi
     if [ -n "$KUBEVIRT_HUGEPAGES_2M" ]; then
         params=" --hugepages-2m $KUBEVIRT_HUGEPAGES_2M $params"
@@ -210,6 +313,14 @@ function _add_common_params() {
         params=" --enable-cnao $params"
     fi
 
+    if [ "$KUBEVIRT_DEPLOY_WHEREABOUTS" == "true" ]; then
+        params=" --deploy-whereabouts $params"
+    fi
+
+    if [ "$KUBEVIRT_WHEREABOUTS_SECONDARY_NETWORKS" == "true" ]; then
+        params=" --whereabouts-secondary-networks $params"
+    fi
+
     if [ "$KUBEVIRT_WITH_DYN_NET_CTRL" == "true" ]; then
         params=" --deploy-dnc $params"
     fi
@@ -230,6 +341,98 @@ function _add_common_params() {
         params=" --aaq-version=$KUBEVIRT_CUSTOM_AAQ_VERSION $params"
     fi
 
+    if [ "$KUBEVIRT_DEPLOY_KUBEVIRT" == "true" ]; then
+        params=" --deploy-kubevirt $params"
+    fi
+
+    if [ -n "$KUBEVIRT_CUSTOM_KUBEVIRT_VERSION" ]; then
+        params=" --kubevirt-version=$KUBEVIRT_CUSTOM_KUBEVIRT_VERSION $params"
+    fi
+
+    if [ -n "$KUBEVIRT_KUBEVIRT_MANIFESTS" ]; then
+        params=" --kubevirt-manifests=$(realpath ${KUBEVIRT_KUBEVIRT_MANIFESTS}) $params"
+    fi
+
+    if [ -n "$KUBEVIRT_KUBEVIRT_FEATURE_GATES" ]; then
+        params=" --kubevirt-feature-gates=$KUBEVIRT_KUBEVIRT_FEATURE_GATES $params"
+    fi
+
+    if [ -n "$KUBEVIRT_KUBEVIRT_CONFIG" ]; then
+        params=" --kubevirt-config-file=$(realpath ${KUBEVIRT_KUBEVIRT_CONFIG}) $params"
+    fi
+
+    if [ "$KUBEVIRT_KUBEVIRT_AUTO_EMULATION" == "true" ]; then
+        params=" --kubevirt-auto-emulation $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_METALLB" == "true" ]; then
+        params=" --enable-metallb $params"
+    fi
+
+    if [ -n "$KUBEVIRT_METALLB_ADDRESS_POOL" ]; then
+        params=" --metallb-address-pool=$KUBEVIRT_METALLB_ADDRESS_POOL $params"
+    fi
+
+    if [ -n "$KUBEVIRT_METALLB_INTERFACES" ]; then
+        params=" --metallb-interfaces=$KUBEVIRT_METALLB_INTERFACES $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_INGRESS" == "true" ]; then
+        params=" --deploy-ingress $params"
+    fi
+
+    if [ -n "$KUBEVIRT_INGRESS_DOMAIN" ]; then
+        params=" --ingress-domain=$KUBEVIRT_INGRESS_DOMAIN $params"
+    fi
+
+    if [ -n "$KUBEVIRT_INGRESS_ADDRESS" ]; then
+        params=" --ingress-address=$KUBEVIRT_INGRESS_ADDRESS $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_CERT_MANAGER" == "true" ]; then
+        params=" --deploy-cert-manager $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_VELERO" == "true" ]; then
+        params=" --deploy-velero $params"
+    fi
+
+    if [ -n "$KUBEVIRT_VELERO_MINIO" ]; then
+        params=" --velero-minio=$KUBEVIRT_VELERO_MINIO $params"
+    fi
+
+    if [ "$KUBEVIRT_VELERO_KUBEVIRT_PLUGIN" == "true" ]; then
+        params=" --velero-kubevirt-plugin $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_DRA" == "true" ]; then
+        params=" --enable-dra $params"
+    fi
+
+    if [ -n "$KUBEVIRT_DRA_DEVICES" ]; then
+        params=" --dra-devices=$KUBEVIRT_DRA_DEVICES $params"
+    fi
+
+    if [ -n "$KUBEVIRT_NUM_SRIOV_NICS" ]; then
+        params=" --sriov-nics=$KUBEVIRT_NUM_SRIOV_NICS $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_SRIOV" == "true" ]; then
+        params=" --deploy-sriov $params"
+    fi
+
+    if [ -n "$KUBEVIRT_SRIOV_VFS" ]; then
+        params=" --sriov-vfs=$KUBEVIRT_SRIOV_VFS $params"
+    fi
+
+    if [ "$KUBEVIRT_DEPLOY_KWOK" == "true" ] || [ -n "$KUBEVIRT_KWOK_NODES" ]; then
+        params=" --kwok-nodes=${KUBEVIRT_KWOK_NODES:-0} $params"
+    fi
+
+    if [ -n "$KUBEVIRT_KWOK_NODE_TEMPLATE" ]; then
+        params=" --kwok-node-template=$(realpath ${KUBEVIRT_KWOK_NODE_TEMPLATE}) $params"
+    fi
+
     if [ "$KUBEVIRT_KSM_ON" == "true" ]; then
         params=" --enable-ksm $params"
     fi
@@ -270,6 +473,10 @@ function _add_common_params() {
         params=" --deploy-network-resources-injector $params"
     fi
 
+    for addon_dir in ${KUBEVIRT_ADDONS}; do
+        params=" --addon $(realpath ${addon_dir}) $params"
+    done
+
     if [ -n "$KUBEVIRT_TOPOLOGY_MANAGER_POLICY" ]; then
         params=" --topology-manager-policy=$KUBEVIRT_TOPOLOGY_MANAGER_POLICY $params"
     fi
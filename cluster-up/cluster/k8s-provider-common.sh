@@ -7,13 +7,6 @@ source "${KUBEVIRTCI_PATH}/cluster/ephemeral-provider-common.sh"
 
 
 
-function deploy_kwok() {
-    if [[ ${KUBEVIRT_DEPLOY_KWOK} == "true" ]]; then
-        $kubectl create -f /opt/kwok/kwok.yaml
-        $kubectl create -f /opt/kwok/stage-fast.yaml
-    fi
-}
-
 # copy_istio_cni_conf_files copy the generated Istio CNI net conf file
 # (at '/etc/cni/multus/net.d/') to where Multus expect CNI net conf files ('/etc/cni/net.d/')
 function copy_istio_cni_conf_files() {
@@ -33,12 +26,6 @@ function configure_prometheus() {
 }
 
 
-function wait_for_kwok_ready() {
-    if [ "KUBEVIRT_DEPLOY_KWOK" == "true" ]; then
-        $kubectl wait deployment -n kube-system kwok-controller --for condition=Available --timeout=200s
-    fi
-}
-
 function configure_nfs() {
     if [[ "$KUBEVIRT_DEPLOY_NFS_CSI" == "true" ]] && [[ -n "$KUBEVIRT_NFS_DIR" ]]; then
         ${_cri_bin} run --privileged --rm -v /:/hostroot \
@@ -85,13 +72,6 @@ function up() {
 
     configure_prometheus
 
-    deploy_kwok
-
-    until wait_for_kwok_ready; do
-        echo "Waiting for cluster components..."
-        sleep 5
-    done
-
     # FIXME: remove 'copy_istio_cni_conf_files()' as soon as [1] and [2] are resolved
     # [1] https://github.com/kubevirt/kubevirtci/issues/906
     # [2] https://github.com/k8snetworkplumbingwg/multus-cni/issues/982
@@ -27,14 +27,30 @@ KUBEVIRT_NUM_SECONDARY_NICS=${KUBEVIRT_NUM_SECONDARY_NICS:-0}
 KUBEVIRT_SECONDARY_NIC_BRIDGES=${KUBEVIRT_SECONDARY_NIC_BRIDGES:-false}
 KUBEVIRT_DEPLOY_ISTIO=${KUBEVIRT_DEPLOY_ISTIO:-false}
 KUBEVIRT_PSA=${KUBEVIRT_PSA:-false}
+KUBEVIRT_PSA_ENFORCE=${KUBEVIRT_PSA_ENFORCE}
+KUBEVIRT_PSA_AUDIT=${KUBEVIRT_PSA_AUDIT}
+KUBEVIRT_PSA_WARN=${KUBEVIRT_PSA_WARN}
+KUBEVIRT_PSA_EXEMPT_NAMESPACES=${KUBEVIRT_PSA_EXEMPT_NAMESPACES}
+KUBEVIRT_PSA_CONFIG=${KUBEVIRT_PSA_CONFIG}
 KUBEVIRT_SINGLE_STACK=${KUBEVIRT_SINGLE_STACK:-false}
 KUBEVIRT_FLANNEL=${KUBEVIRT_FLANNEL:-true}
+KUBEVIRT_CNI=${KUBEVIRT_CNI}
 KUBEVIRT_NO_ETCD_FSYNC=${KUBEVIRT_NO_ETCD_FSYNC:-false}
 KUBEVIRT_ENABLE_AUDIT=${KUBEVIRT_ENABLE_AUDIT:-false}
 KUBEVIRT_DEPLOY_NFS_CSI=${KUBEVIRT_DEPLOY_NFS_CSI:-false}
+KUBEVIRT_NFS_TMPFS_SIZE=${KUBEVIRT_NFS_TMPFS_SIZE}
+KUBEVIRT_NFS_EXPORT_OPTIONS=${KUBEVIRT_NFS_EXPORT_OPTIONS}
+KUBEVIRT_NFS_SECOND_EXPORT=${KUBEVIRT_NFS_SECOND_EXPORT:-false}
+KUBEVIRT_CEPH_OSDS=${KUBEVIRT_CEPH_OSDS}
+KUBEVIRT_CEPH_OSD_SIZE=${KUBEVIRT_CEPH_OSD_SIZE}
+KUBEVIRT_CEPH_REPLICAS=${KUBEVIRT_CEPH_REPLICAS}
+KUBEVIRT_CEPH_FILESYSTEM=${KUBEVIRT_CEPH_FILESYSTEM:-false}
+KUBEVIRT_CEPH_OBJECT_STORE=${KUBEVIRT_CEPH_OBJECT_STORE:-false}
 KUBEVIRT_DEPLOY_PROMETHEUS=${KUBEVIRT_DEPLOY_PROMETHEUS:-false}
 KUBEVIRT_DEPLOY_PROMETHEUS_ALERTMANAGER=${KUBEVIRT_DEPLOY_PROMETHEUS_ALERTMANAGER-false}
 KUBEVIRT_DEPLOY_GRAFANA=${KUBEVIRT_DEPLOY_GRAFANA:-false}
+KUBEVIRT_PROMETHEUS_EXTRA=${KUBEVIRT_PROMETHEUS_EXTRA}
+KUBEVIRT_DEPLOY_LOGGING=${KUBEVIRT_DEPLOY_LOGGING:-false}
 KUBEVIRT_CGROUPV2=${KUBEVIRT_CGROUPV2:-true}
 KUBEVIRT_WITH_SRIOV=${KUBEVIRT_WITH_SRIOV:-false}
 KUBEVIRT_USE_FAKE_VFIO=${KUBEVIRT_USE_FAKE_VFIO:-false}
@@ -42,6 +58,32 @@ KUBEVIRT_DEPLOY_CDI=${KUBEVIRT_DEPLOY_CDI:-false}
 KUBEVIRT_DEPLOY_AAQ=${KUBEVIRT_DEPLOY_AAQ:-false}
 KUBEVIRT_CUSTOM_AAQ_VERSION=${KUBEVIRT_CUSTOM_AAQ_VERSION}
 KUBEVIRT_CUSTOM_CDI_VERSION=${KUBEVIRT_CUSTOM_CDI_VERSION}
+KUBEVIRT_DEPLOY_KUBEVIRT=${KUBEVIRT_DEPLOY_KUBEVIRT:-false}
+KUBEVIRT_CUSTOM_KUBEVIRT_VERSION=${KUBEVIRT_CUSTOM_KUBEVIRT_VERSION}
+KUBEVIRT_KUBEVIRT_MANIFESTS=${KUBEVIRT_KUBEVIRT_MANIFESTS}
+KUBEVIRT_KUBEVIRT_FEATURE_GATES=${KUBEVIRT_KUBEVIRT_FEATURE_GATES}
+KUBEVIRT_KUBEVIRT_CONFIG=${KUBEVIRT_KUBEVIRT_CONFIG}
+KUBEVIRT_KUBEVIRT_AUTO_EMULATION=${KUBEVIRT_KUBEVIRT_AUTO_EMULATION:-false}
+KUBEVIRT_DEPLOY_METALLB=${KUBEVIRT_DEPLOY_METALLB:-false}
+KUBEVIRT_METALLB_ADDRESS_POOL=${KUBEVIRT_METALLB_ADDRESS_POOL}
+KUBEVIRT_METALLB_INTERFACES=${KUBEVIRT_METALLB_INTERFACES}
+KUBEVIRT_DEPLOY_INGRESS=${KUBEVIRT_DEPLOY_INGRESS:-false}
+KUBEVIRT_INGRESS_DOMAIN=${KUBEVIRT_INGRESS_DOMAIN}
+KUBEVIRT_INGRESS_ADDRESS=${KUBEVIRT_INGRESS_ADDRESS}
+KUBEVIRT_DEPLOY_CERT_MANAGER=${KUBEVIRT_DEPLOY_CERT_MANAGER:-false}
+KUBEVIRT_DEPLOY_VELERO=${KUBEVIRT_DEPLOY_VELERO:-false}
+KUBEVIRT_VELERO_MINIO=${KUBEVIRT_VELERO_MINIO}
+KUBEVIRT_VELERO_KUBEVIRT_PLUGIN=${KUBEVIRT_VELERO_KUBEVIRT_PLUGIN:-false}
+KUBEVIRT_DEPLOY_DRA=${KUBEVIRT_DEPLOY_DRA:-false}
+KUBEVIRT_DRA_DEVICES=${KUBEVIRT_DRA_DEVICES}
+KUBEVIRT_NUM_SRIOV_NICS=${KUBEVIRT_NUM_SRIOV_NICS}
+KUBEVIRT_DEPLOY_SRIOV=${KUBEVIRT_DEPLOY_SRIOV:-false}
+KUBEVIRT_SRIOV_VFS=${KUBEVIRT_SRIOV_VFS}
+KUBEVIRT_DEPLOY_KWOK=${KUBEVIRT_DEPLOY_KWOK:-false}
+KUBEVIRT_KWOK_NODES=${KUBEVIRT_KWOK_NODES}
+KUBEVIRT_KWOK_NODE_TEMPLATE=${KUBEVIRT_KWOK_NODE_TEMPLATE}
+KUBEVIRT_DEPLOY_WHEREABOUTS=${KUBEVIRT_DEPLOY_WHEREABOUTS:-false}
+KUBEVIRT_WHEREABOUTS_SECONDARY_NETWORKS=${KUBEVIRT_WHEREABOUTS_SECONDARY_NETWORKS:-false}
 KUBEVIRT_SWAP_ON=${KUBEVIRT_SWAP_ON:-false}
 KUBEVIRT_SWAP_BEHAVIOR=${KUBEVIRT_SWAP_BEHAVIOR:-}
 KUBEVIRT_KSM_ON=${KUBEVIRT_KSM_ON:-false}
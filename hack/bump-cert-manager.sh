@@ -0,0 +1,50 @@
+#!/usr/bin/env bash
+#
+# This file is part of the KubeVirt project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# Copyright The KubeVirt Authors.
+
+set -e
+
+CERT_MANAGER_RELEASES="https://github.com/cert-manager/cert-manager/releases/download"
+
+# syntax:
+# ./hack/bump-cert-manager.sh <CERT_MANAGER_VERSION>
+
+# usage example
+# ./hack/bump-cert-manager.sh v1.16.2
+
+function main() {
+    cert_manager_version="${1:?cert-manager version not set or empty}"
+
+    url="${CERT_MANAGER_RELEASES}/${cert_manager_version}/cert-manager.yaml"
+    target="./cluster-provision/gocli/opts/certmanager/manifests/cert-manager.yaml"
+    if ! ls "${target}" > /dev/null; then
+        echo "${target} not found at kubevirtci folder"
+        exit 1
+    fi
+
+    manifest=$(curl -Lsf "${url}") || {
+        echo "${url} not found"
+        exit 1
+    }
+
+    # the release manifest is embedded unmodified
+    echo "${manifest}" > "${target}"
+
+    echo "cert-manager, provision, Bump cert-manager to ${cert_manager_version}"
+}
+
+main "$@"
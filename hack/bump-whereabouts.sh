@@ -50,7 +50,7 @@ manifests_dir=doc
 cp hack/kustomization/whereabouts/*.yaml ${tmp_dir}/whereabouts/${manifests_dir}/
 sed -i "s/##VERSION##/${whereabouts_version}/" ${tmp_dir}/whereabouts/${manifests_dir}/kustomization.yaml
 
-target_dir="cluster-provision/gocli/opts/cnao/manifests/"
+target_dir="cluster-provision/gocli/opts/whereabouts/manifests/"
 mkdir -p ${target_dir}
 rm -rf ${target_dir:?}/whereabouts.yaml
 kubectl kustomize ${tmp_dir}/whereabouts/${manifests_dir}/ >${target_dir}/whereabouts.yaml